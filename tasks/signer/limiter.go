@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter ограничивает вызовы общего ресурса, например DataSignerMd5.
+// Один Limiter можно передать всем звеньям конвейера, которые используют ресурс.
+type Limiter interface {
+	// Acquire ждёт разрешения на вызов или отмены ctx
+	Acquire(ctx context.Context) error
+	// Release сообщает, что вызов завершён
+	Release()
+}
+
+// semaphore пропускает не больше n вызовов одновременно
+type semaphore chan struct{}
+
+// NewSemaphore возвращает Limiter на n одновременных вызовов.
+// DataSignerMd5 перегревается при параллельных вызовах, поэтому для него n = 1.
+func NewSemaphore(n int) Limiter {
+	if n < 1 {
+		n = 1
+	}
+	return make(semaphore, n)
+}
+
+func (s semaphore) Acquire(ctx context.Context) error {
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+func (s semaphore) Release() {
+	<-s
+}
+
+// tokenBucket выдаёт разрешение раз в interval, накапливая до burst разрешений
+type tokenBucket struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    int
+	next     time.Time // когда разрешения закончатся, если их не брать
+}
+
+// NewTokenBucket возвращает Limiter на один вызов в interval с запасом в burst
+// вызовов. Он ограничивает частоту, а не одновременность: вызовы не
+// пересекаются, только если каждый короче interval.
+func NewTokenBucket(interval time.Duration, burst int) Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{interval: interval, burst: burst}
+}
+
+func (b *tokenBucket) Acquire(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	if b.next.Before(now) {
+		b.next = now
+	}
+	// разрешение резервируется сразу, при отмене оно пропадает
+	at := b.next.Add(-time.Duration(b.burst-1) * b.interval)
+	b.next = b.next.Add(b.interval)
+	b.mu.Unlock()
+
+	wait := time.Until(at)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+func (b *tokenBucket) Release() {}
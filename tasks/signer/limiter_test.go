@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// overheatSigners подменяет DataSignerMd5 на быструю версию, которая вместо
+// ожидания в OverheatLock считает случаи перегрева
+func overheatSigners(t testing.TB) *uint32 {
+	fastSigners(t, 0)
+	lock, unlock := OverheatLock, OverheatUnlock
+	t.Cleanup(func() {
+		OverheatLock, OverheatUnlock = lock, unlock
+	})
+
+	var overheats uint32
+	OverheatLock = func() {
+		if !atomic.CompareAndSwapUint32(&dataSignerOverheat, 0, 1) {
+			atomic.AddUint32(&overheats, 1)
+		}
+	}
+	OverheatUnlock = func() {
+		atomic.StoreUint32(&dataSignerOverheat, 0)
+	}
+	DataSignerMd5 = func(data string) string {
+		OverheatLock()
+		defer OverheatUnlock()
+		time.Sleep(100 * time.Microsecond)
+		return "md5(" + data + ")"
+	}
+	return &overheats
+}
+
+func TestLimiterNoOverheat(t *testing.T) {
+	checkGoroutines(t)
+	overheats := overheatSigners(t)
+
+	inputs := []int{}
+	for i := 0; i < 200; i++ {
+		inputs = append(inputs, i)
+	}
+
+	// несколько конвейеров одновременно с общим ограничителем
+	opts := StageOptions{Md5Limiter: NewSemaphore(1)}
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := Run(context.Background(), Then(SingleHashWith(opts), MultiHashWith(opts)), inputs...)
+			if err != nil || len(result) != len(inputs) {
+				t.Errorf("wrong results\nGot: %d %v\nExpected: %d", len(result), err, len(inputs))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadUint32(overheats); got != 0 {
+		t.Errorf("md5 overheated\nGot: %d\nExpected: %d", got, 0)
+	}
+}
+
+func TestSemaphore(t *testing.T) {
+	limiter := NewSemaphore(3)
+	calls := &concurrency{}
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := limiter.Acquire(context.Background()); err != nil {
+				t.Error(err)
+				return
+			}
+			calls.track(func() { time.Sleep(time.Millisecond) })
+			limiter.Release()
+		}()
+	}
+	wg.Wait()
+
+	if calls.max > 3 {
+		t.Errorf("too many concurrent calls\nGot: %d\nExpected: <=%d", calls.max, 3)
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	for i := 0; i < 3; i++ {
+		limiter.Acquire(ctx)
+	}
+	cancel(ErrBadInput)
+	if err := limiter.Acquire(ctx); !errors.Is(err, ErrBadInput) {
+		t.Errorf("Acquire ignores cancellation\nGot:\n%v\nExpected:\n%v", err, ErrBadInput)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	interval := 20 * time.Millisecond
+	limiter := NewTokenBucket(interval, 3)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		limiter.Acquire(context.Background())
+	}
+	if elapsed := time.Since(start); elapsed >= interval {
+		t.Errorf("burst is not available at once\nGot: %v\nExpected: <%v", elapsed, interval)
+	}
+
+	for i := 0; i < 3; i++ {
+		limiter.Acquire(context.Background())
+		limiter.Release()
+	}
+	if elapsed, expected := time.Since(start), 3*interval; elapsed < expected {
+		t.Errorf("rate is not limited\nGot: %v\nExpected: >=%v", elapsed, expected)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Acquire ignores cancellation\nGot:\n%v\nExpected:\n%v", err, context.Canceled)
+	}
+}
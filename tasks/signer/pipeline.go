@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+)
+
+// ctxJob - звено конвейера на interface{}, которое прекращает работу
+// при отмене ctx и может завершиться с ошибкой
+type ctxJob = Stage[interface{}, interface{}]
+
+// FromJob позволяет запустить обычное звено как Stage. Такое звено не знает
+// об отмене, но не зависнет на отправке: его вход и выход вычитываются до конца.
+func FromJob(j job) Stage[interface{}, interface{}] {
+	return func(ctx context.Context, in <-chan interface{}, out chan<- interface{}) error {
+
+		jobIn, jobOut := make(chan interface{}), make(chan interface{})
+		fed := make(chan struct{})
+
+		go func() {
+			defer close(fed)
+			defer close(jobIn)
+			for {
+				value, ok := receive(ctx, in)
+				if !ok || send(ctx, jobIn, value) != nil {
+					return
+				}
+			}
+		}()
+
+		go func() {
+			j(jobIn, jobOut)
+			close(jobOut)
+		}()
+
+		for value := range jobOut {
+			// после отмены выход звена просто вычитывается
+			send(ctx, out, value)
+		}
+		drain(jobIn)
+		<-fed
+
+		return context.Cause(ctx)
+	}
+}
+
+// ExecutePipelineContext запускает звенья конвейером и ждёт завершения всех,
+// включая горутины, которые вычитывают недочитанные каналы. Первая ошибка
+// звена отменяет остальные и возвращается, как и отмена самого ctx.
+func ExecutePipelineContext(ctx context.Context, jobs ...ctxJob) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	pipeline := jobs[0]
+	for _, j := range jobs[1:] {
+		pipeline = Then(pipeline, j)
+	}
+
+	// первому звену читать нечего, выход последнего никому не нужен
+	return run(ctx, pipeline, nil, func(interface{}) {})
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// checkGoroutines - упрощённый аналог goleak: запоминает число горутин
+// и проверяет, что после теста оно вернулось к исходному
+func checkGoroutines(t *testing.T) {
+	t.Helper()
+	before := runtime.NumGoroutine()
+	t.Cleanup(func() {
+		deadline := time.Now().Add(2 * time.Second)
+		for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if after := runtime.NumGoroutine(); after > before {
+			buf := make([]byte, 1<<16)
+			t.Errorf("goroutines leaked\nGot: %d\nExpected: %d\n%s", after, before, buf[:runtime.Stack(buf, true)])
+		}
+	})
+}
+
+// endless отправляет числа, пока конвейер не отменят
+func endless(ctx context.Context, in <-chan interface{}, out chan<- interface{}) error {
+	for i := 0; ; i++ {
+		if err := send[interface{}](ctx, out, i); err != nil {
+			return err
+		}
+	}
+}
+
+func TestPipelineContextError(t *testing.T) {
+	checkGoroutines(t)
+
+	errStop := errors.New("stop")
+	var processed uint32
+
+	err := ExecutePipelineContext(context.Background(),
+		endless,
+		func(ctx context.Context, in <-chan interface{}, out chan<- interface{}) error {
+			for {
+				value, ok := receive(ctx, in)
+				if !ok {
+					return context.Cause(ctx)
+				}
+				if value.(int) == 10 {
+					return errStop
+				}
+				if err := send(ctx, out, value); err != nil {
+					return err
+				}
+			}
+		},
+		func(ctx context.Context, in <-chan interface{}, out chan<- interface{}) error {
+			for range in {
+				atomic.AddUint32(&processed, 1)
+			}
+			return nil
+		},
+	)
+
+	if err != errStop {
+		t.Errorf("wrong error\nGot: %v\nExpected: %v", err, errStop)
+	}
+	if processed != 10 {
+		t.Errorf("wrong number of processed values\nGot: %d\nExpected: %d", processed, 10)
+	}
+}
+
+func TestPipelineContextCancel(t *testing.T) {
+	checkGoroutines(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	err := ExecutePipelineContext(ctx,
+		endless,
+		func(ctx context.Context, in <-chan interface{}, out chan<- interface{}) error {
+			for range in {
+			}
+			return nil
+		},
+	)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("wrong error\nGot: %v\nExpected: %v", err, context.Canceled)
+	}
+}
+
+// звено без контекста не должно зависнуть на отправке, когда следующее
+// звено уже завершилось с ошибкой
+func TestPipelineContextDrain(t *testing.T) {
+	checkGoroutines(t)
+
+	errStop := errors.New("stop")
+	var sent uint32
+
+	err := ExecutePipelineContext(context.Background(),
+		FromJob(func(in, out chan interface{}) {
+			for i := 0; i < 100; i++ {
+				out <- i
+				atomic.AddUint32(&sent, 1)
+			}
+		}),
+		func(ctx context.Context, in <-chan interface{}, out chan<- interface{}) error {
+			<-in
+			return errStop
+		},
+	)
+
+	if err != errStop {
+		t.Errorf("wrong error\nGot: %v\nExpected: %v", err, errStop)
+	}
+	if sent != 100 {
+		t.Errorf("producer blocked\nGot: %d\nExpected: %d", sent, 100)
+	}
+}
+
+func TestPipelineContextBadInput(t *testing.T) {
+	checkGoroutines(t)
+
+	err := ExecutePipelineContext(context.Background(),
+		func(ctx context.Context, in <-chan interface{}, out chan<- interface{}) error {
+			return send[interface{}](ctx, out, "not a number")
+		},
+		SingleHashContext,
+		MultiHashContext,
+		CombineResultsContext,
+	)
+
+	if !errors.Is(err, ErrBadInput) {
+		t.Errorf("wrong error\nGot: %v\nExpected: %v", err, ErrBadInput)
+	}
+	expected := "SingleHash: unexpected input type string, expected int"
+	if err == nil || err.Error() != expected {
+		t.Errorf("wrong message\nGot: %v\nExpected: %v", err, expected)
+	}
+}
+
+func TestPipelineContextSigner(t *testing.T) {
+	checkGoroutines(t)
+
+	result := ""
+	err := ExecutePipelineContext(context.Background(),
+		func(ctx context.Context, in <-chan interface{}, out chan<- interface{}) error {
+			for _, n := range []int{0, 1} {
+				if err := send[interface{}](ctx, out, n); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		SingleHashContext,
+		MultiHashContext,
+		CombineResultsContext,
+		func(ctx context.Context, in <-chan interface{}, out chan<- interface{}) error {
+			value, _ := receive(ctx, in)
+			result, _ = value.(string)
+			return nil
+		},
+	)
+
+	expected := "29568666068035183841425683795340791879727309630931025356555_4958044192186797981418233587017209679042592862002427381542"
+	if err != nil || result != expected {
+		t.Errorf("results not match\nGot: %v %v\nExpected: %v", result, err, expected)
+	}
+}
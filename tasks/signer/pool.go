@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// StageOptions - ограничения параллельности SingleHash и MultiHash.
+// Нулевые значения - без ограничений.
+type StageOptions struct {
+	MaxInFlight int // сколько входных значений обрабатывается одновременно
+	Workers     int // сколько DataSignerCrc32 выполняется одновременно
+	Buffer      int // сколько готовых результатов может ждать следующее звено
+
+	Ordered       bool // отдавать результаты в порядке входных значений
+	ReorderBuffer int  // сколько значений может быть прочитано, но ещё не отдано в режиме Ordered
+
+	// Md5Limiter защищает DataSignerMd5 от перегрева. Если он не задан,
+	// у каждого запуска SingleHash свой NewSemaphore(1); если DataSignerMd5
+	// вызывают несколько звеньев, им нужен один общий Limiter.
+	Md5Limiter Limiter
+}
+
+// workerPool выполняет задачи не более чем в workers горутинах
+type workerPool struct {
+	tasks chan func()
+	wg    sync.WaitGroup
+}
+
+// newWorkerPool при workers <= 0 возвращает пул, в котором у каждой задачи своя горутина
+func newWorkerPool(workers int) *workerPool {
+	p := &workerPool{}
+	if workers <= 0 {
+		return p
+	}
+	p.tasks = make(chan func())
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for task := range p.tasks {
+				task()
+			}
+		}()
+	}
+	return p
+}
+
+// do выполняет задачи параллельно и ждёт завершения всех.
+// Если все воркеры заняты, do ждёт свободного. После отмены ctx
+// оставшиеся задачи не запускаются.
+func (p *workerPool) do(ctx context.Context, tasks ...func()) {
+	wg := &sync.WaitGroup{}
+	for _, task := range tasks {
+		wg.Add(1)
+		run := func() {
+			defer wg.Done()
+			if ctx.Err() == nil {
+				task()
+			}
+		}
+		if p.tasks == nil {
+			go run()
+			continue
+		}
+		select {
+		case p.tasks <- run:
+		case <-ctx.Done():
+			wg.Done()
+		}
+	}
+	wg.Wait()
+}
+
+// stop завершает воркеры, задач после него быть не должно
+func (p *workerPool) stop() {
+	if p.tasks != nil {
+		close(p.tasks)
+	}
+	p.wg.Wait()
+}
+
+// sequenced - результат с номером входного значения
+type sequenced[T any] struct {
+	seq   int
+	value T
+}
+
+// process читает значения из in и обрабатывает каждое в отдельной горутине
+// через work. Если обрабатывается opts.MaxInFlight значений, новые не
+// читаются, пока готовый результат не окажется в буфере на opts.Buffer
+// значений: так медленное следующее звено притормаживает всё, что перед ним.
+//
+// По умолчанию результаты отдаются по мере готовности. В режиме opts.Ordered
+// они ждут предыдущих в буфере перестановки, и новые значения не читаются,
+// пока прочитано, но не отдано opts.ReorderBuffer значений.
+//
+// После отмены ctx значения не обрабатываются, а результаты отбрасываются,
+// поэтому work может прерваться и вернуть что угодно.
+func process[In, Out any](ctx context.Context, in <-chan In, out chan<- Out, opts StageOptions, work func(In) Out) error {
+
+	var slots, window chan struct{}
+	if opts.MaxInFlight > 0 {
+		slots = make(chan struct{}, opts.MaxInFlight)
+	}
+	if opts.Ordered && opts.ReorderBuffer > 0 {
+		window = make(chan struct{}, opts.ReorderBuffer)
+	}
+
+	results := make(chan sequenced[Out], opts.Buffer)
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+
+		next := 0
+		pending := map[int]Out{}
+		for result := range results {
+			// после отмены результаты отбрасываются: send мог бы
+			// отдать значение, посчитанное не до конца
+			if ctx.Err() != nil {
+				continue
+			}
+			if !opts.Ordered {
+				send(ctx, out, result.value)
+				continue
+			}
+
+			pending[result.seq] = result.value
+			for value, ok := pending[next]; ok; value, ok = pending[next] {
+				delete(pending, next)
+				send(ctx, out, value)
+				next++
+				if window != nil {
+					<-window
+				}
+			}
+		}
+	}()
+
+	wg := &sync.WaitGroup{}
+read:
+	for seq := 0; ; seq++ {
+		for _, sem := range []chan struct{}{window, slots} {
+			if sem == nil {
+				continue
+			}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				break read
+			}
+		}
+		value, ok := receive(ctx, in)
+		if !ok {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if slots != nil {
+				defer func() { <-slots }()
+			}
+
+			// после отмены значение не обрабатывается
+			if ctx.Err() != nil {
+				return
+			}
+			result := work(value)
+			if ctx.Err() != nil {
+				return
+			}
+			results <- sequenced[Out]{seq: seq, value: result}
+		}()
+	}
+
+	wg.Wait()
+	close(results)
+	<-forwarded
+
+	return context.Cause(ctx)
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrency считает, сколько вызовов выполнялось одновременно
+type concurrency struct {
+	current int32
+	max     int32
+}
+
+func (c *concurrency) track(f func()) {
+	n := atomic.AddInt32(&c.current, 1)
+	for {
+		max := atomic.LoadInt32(&c.max)
+		if n <= max || atomic.CompareAndSwapInt32(&c.max, max, n) {
+			break
+		}
+	}
+	f()
+	atomic.AddInt32(&c.current, -1)
+}
+
+// fastSigners подменяет функции расчёта на быстрые, которые считают
+// одновременные вызовы DataSignerCrc32, и возвращает исходные после теста
+func fastSigners(t testing.TB, crcDelay time.Duration) *concurrency {
+	crc32, md5 := DataSignerCrc32, DataSignerMd5
+	t.Cleanup(func() {
+		DataSignerCrc32, DataSignerMd5 = crc32, md5
+	})
+
+	c := &concurrency{}
+	DataSignerCrc32 = func(data string) string {
+		c.track(func() { time.Sleep(crcDelay) })
+		return strconv.Itoa(len(data))
+	}
+	DataSignerMd5 = func(data string) string {
+		return "md5(" + data + ")"
+	}
+	return c
+}
+
+func TestStageOptionsLimits(t *testing.T) {
+	checkGoroutines(t)
+
+	inputs := []string{}
+	for i := 0; i < 20; i++ {
+		inputs = append(inputs, fmt.Sprint(i))
+	}
+
+	cases := []struct {
+		opts     StageOptions
+		expected int32 // сколько DataSignerCrc32 может выполняться одновременно
+	}{
+		{opts: StageOptions{Workers: 3}, expected: 3},
+		{opts: StageOptions{MaxInFlight: 2}, expected: 2 * 6},
+		{opts: StageOptions{MaxInFlight: 4, Workers: 5, Buffer: 2}, expected: 5},
+	}
+
+	for _, c := range cases {
+		calls := fastSigners(t, 5*time.Millisecond)
+
+		result, err := Run(context.Background(), MultiHashWith(c.opts), inputs...)
+		if err != nil || len(result) != len(inputs) {
+			t.Errorf("wrong results with %+v\nGot: %d %v\nExpected: %d", c.opts, len(result), err, len(inputs))
+		}
+		if calls.max > c.expected {
+			t.Errorf("too many concurrent calls with %+v\nGot: %d\nExpected: <=%d", c.opts, calls.max, c.expected)
+		}
+	}
+}
+
+func TestStageOptionsBackpressure(t *testing.T) {
+	checkGoroutines(t)
+	fastSigners(t, time.Millisecond)
+
+	opts := StageOptions{MaxInFlight: 3, Buffer: 2}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in, out := make(chan int), make(chan string)
+	var read int32
+
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer close(in)
+		for i := 0; ; i++ {
+			if send(ctx, in, i) != nil {
+				return
+			}
+			atomic.AddInt32(&read, 1)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		SingleHashWith(opts)(ctx, in, out)
+	}()
+
+	// следующее звено ничего не читает: после буфера и обрабатываемых значений
+	// одно ещё ждёт отправки, дальше звено читать не должно
+	time.Sleep(100 * time.Millisecond)
+	if got, expected := atomic.LoadInt32(&read), int32(opts.MaxInFlight+opts.Buffer+1); got != expected {
+		t.Errorf("stage ignores backpressure\nGot: %d\nExpected: %d", got, expected)
+	}
+
+	cancel()
+	wg.Wait()
+}
+
+func TestStageOptionsCancel(t *testing.T) {
+	checkGoroutines(t)
+	fastSigners(t, 50*time.Millisecond)
+
+	inputs := []string{}
+	for i := 0; i < 40; i++ {
+		inputs = append(inputs, fmt.Sprint(i))
+	}
+
+	// все значения уже прочитаны и ждут единственного воркера,
+	// после отмены их DataSignerCrc32 не должны запускаться
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result, err := Run(ctx, MultiHashWith(StageOptions{Workers: 1}), inputs...)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) || len(result) != 0 {
+		t.Errorf("wrong result after cancel\nGot:\n%v %v\nExpected:\n[] %v", result, err, context.DeadlineExceeded)
+	}
+	if elapsed > 500*time.Millisecond {
+		t.Errorf("stage is not stopped promptly\nGot: %v\nExpected: <=%v", elapsed, 500*time.Millisecond)
+	}
+}
+
+// randomSigners подменяет DataSignerCrc32 на функцию со случайной задержкой,
+// результат которой различается для разных данных
+func randomSigners(t testing.TB) {
+	fastSigners(t, 0)
+	DataSignerCrc32 = func(data string) string {
+		time.Sleep(time.Duration(rand.Intn(10)) * time.Millisecond)
+		return "crc(" + data + ")"
+	}
+}
+
+func TestStageOptionsOrdered(t *testing.T) {
+	checkGoroutines(t)
+	randomSigners(t)
+
+	inputs := []int{}
+	for i := 0; i < 30; i++ {
+		inputs = append(inputs, i)
+	}
+
+	cases := []StageOptions{
+		{Ordered: true},
+		{Ordered: true, ReorderBuffer: 1},
+		{Ordered: true, ReorderBuffer: 4, MaxInFlight: 2, Workers: 3},
+		{Ordered: true, ReorderBuffer: 8, Buffer: 3},
+	}
+
+	for _, opts := range cases {
+		pipeline := Then(SingleHashWith(opts), MultiHashWith(opts))
+
+		expected := []string{}
+		for _, input := range inputs {
+			result, err := Run(context.Background(), pipeline, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected = append(expected, result...)
+		}
+
+		result, err := Run(context.Background(), pipeline, inputs...)
+		if err != nil || fmt.Sprint(result) != fmt.Sprint(expected) {
+			t.Errorf("wrong order with %+v\nGot:\n%v %v\nExpected:\n%v", opts, result, err, expected)
+		}
+	}
+}
+
+func TestStageOptionsReorderBuffer(t *testing.T) {
+	checkGoroutines(t)
+	fastSigners(t, 0)
+
+	// первое значение считается долго, остальные ждут его в буфере перестановки
+	release := make(chan struct{})
+	DataSignerCrc32 = func(data string) string {
+		if data == "0" {
+			<-release
+		}
+		return data
+	}
+
+	opts := StageOptions{Ordered: true, ReorderBuffer: 4}
+
+	in, out := make(chan int), make(chan string)
+	var read int32
+	go func() {
+		defer close(in)
+		for i := 0; i < 10; i++ {
+			in <- i
+			atomic.AddInt32(&read, 1)
+		}
+	}()
+	done := make(chan error, 1)
+	go func() {
+		done <- SingleHashWith(opts)(context.Background(), in, out)
+		close(out)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	if got := atomic.LoadInt32(&read); got != int32(opts.ReorderBuffer) {
+		t.Errorf("reorder buffer is not bounded\nGot: %d\nExpected: %d", got, opts.ReorderBuffer)
+	}
+
+	close(release)
+	result := []string{}
+	for value := range out {
+		result = append(result, value)
+	}
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+	for i, value := range result {
+		if expected := fmt.Sprint(i) + "~"; len(value) < len(expected) || value[:len(expected)] != expected {
+			t.Errorf("wrong order at %d\nGot:\n%v\nExpected:\n%v...", i, value, expected)
+		}
+	}
+	if len(result) != 10 {
+		t.Errorf("wrong results count\nGot: %d\nExpected: %d", len(result), 10)
+	}
+}
+
+// BenchmarkSignerConcurrency показывает, как пропускная способность SingleHash
+// и MultiHash с настоящим DataSignerCrc32 на 1 секунду зависит от MaxInFlight.
+// Запуск: go test -run - -bench SignerConcurrency -benchtime 1x
+func BenchmarkSignerConcurrency(b *testing.B) {
+	inputs := []int{}
+	for i := 0; i < 16; i++ {
+		inputs = append(inputs, i)
+	}
+
+	for _, limit := range []int{1, 2, 4, 8, 0} {
+		b.Run(fmt.Sprint("inflight=", limit), func(b *testing.B) {
+			opts := StageOptions{MaxInFlight: limit}
+			pipeline := Then(SingleHashWith(opts), MultiHashWith(opts))
+
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				if _, err := Run(context.Background(), pipeline, inputs...); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.N*len(inputs))/time.Since(start).Seconds(), "items/s")
+		})
+	}
+}
@@ -1,145 +1,164 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sort"
 	"strings"
-	"sync"
 )
 
+// ErrBadInput - звено получило значение не того типа
+var ErrBadInput = errors.New("unexpected input type")
+
+// logBadInput - поведение обычных звеньев: значение не того типа пропускается
+func logBadInput(err error) error {
+	log.Println(err)
+	return nil
+}
+
+// failOnBadInput - поведение звеньев с контекстом: конвейер останавливается
+func failOnBadInput(err error) error {
+	return err
+}
+
 func SingleHash(in, out chan interface{}) {
+	assertStage("SingleHash", SingleHashStage, logBadInput)(context.Background(), in, out)
+}
 
-	wg := &sync.WaitGroup{}
+// SingleHashContext - SingleHash, который останавливается при отмене ctx
+// и возвращает ErrBadInput вместо пропуска значения
+func SingleHashContext(ctx context.Context, in <-chan interface{}, out chan<- interface{}) error {
+	return assertStage("SingleHash", SingleHashStage, failOnBadInput)(ctx, in, out)
+}
 
-	for dataRaw := range in {
-		dataInt, ok := dataRaw.(int)
-		if !ok {
-			log.Println("Single Hash: can't convert data to int")
-			continue
-		}
-		data := fmt.Sprint(dataInt)
+// SingleHashStage - типизированный SingleHash без ограничений параллельности
+func SingleHashStage(ctx context.Context, in <-chan int, out chan<- string) error {
+	return SingleHashWith(StageOptions{})(ctx, in, out)
+}
 
-		fmt.Print(data, " SingleHash data ", data, "\n")
+// SingleHashWith возвращает SingleHash с ограничениями opts
+func SingleHashWith(opts StageOptions) Stage[int, string] {
+	return func(ctx context.Context, in <-chan int, out chan<- string) error {
 
-		md5Data := DataSignerMd5(data)
-		fmt.Print(data, " SingleHash md5(data) ", md5Data, "\n")
+		pool := newWorkerPool(opts.Workers)
+		defer pool.stop()
 
-		wg.Add(1)
-		go func(data string, md5Data string, wg *sync.WaitGroup) {
-			defer wg.Done()
+		md5Limiter := opts.Md5Limiter
+		if md5Limiter == nil {
+			md5Limiter = NewSemaphore(1)
+		}
 
-			crc32DataCh := make(chan string)
-			crc32Md5DataCh := make(chan string)
+		return process(ctx, in, out, opts, func(dataInt int) string {
+			data := fmt.Sprint(dataInt)
 
-			go func(data string, crc32DataCh chan<- string) {
-				crc32Data := DataSignerCrc32(data)
-				fmt.Print(data, " SingleHash crc32(data) ", crc32Data, "\n")
-				crc32DataCh <- crc32Data
-			}(data, crc32DataCh)
+			fmt.Print(data, " SingleHash data ", data, "\n")
 
-			go func(md5Data string, crc32Md5DataCh chan<- string) {
-				crc32Md5Data := DataSignerCrc32(md5Data)
+			// Acquire ошибается только после отмены, и тогда process отбросит результат
+			if md5Limiter.Acquire(ctx) != nil {
+				return ""
+			}
+			md5Data := DataSignerMd5(data)
+			md5Limiter.Release()
+			fmt.Print(data, " SingleHash md5(data) ", md5Data, "\n")
+
+			var crc32Data, crc32Md5Data string
+			pool.do(ctx, func() {
+				crc32Data = DataSignerCrc32(data)
+				fmt.Print(data, " SingleHash crc32(data) ", crc32Data, "\n")
+			}, func() {
+				crc32Md5Data = DataSignerCrc32(md5Data)
 				fmt.Print(data, " SingleHash crc32(md5(data)) ", crc32Md5Data, "\n")
-				crc32Md5DataCh <- crc32Md5Data
-			}(md5Data, crc32Md5DataCh)
+			})
 
-			result := <-crc32DataCh + "~" + <-crc32Md5DataCh
+			result := crc32Data + "~" + crc32Md5Data
 			fmt.Print(data, " SingleHash result ", result, "\n")
-			out <- result
-		}(data, md5Data, wg)
+			return result
+		})
 	}
-	wg.Wait()
 }
 
 func MultiHash(in, out chan interface{}) {
+	assertStage("MultiHash", MultiHashStage, logBadInput)(context.Background(), in, out)
+}
 
-	wgExt := &sync.WaitGroup{}
+// MultiHashContext - MultiHash, который останавливается при отмене ctx
+// и возвращает ErrBadInput вместо пропуска значения
+func MultiHashContext(ctx context.Context, in <-chan interface{}, out chan<- interface{}) error {
+	return assertStage("MultiHash", MultiHashStage, failOnBadInput)(ctx, in, out)
+}
 
-	for dataRaw := range in {
-		data, ok := dataRaw.(string)
-		if !ok {
-			log.Println("Multi Hash: can't convert data to string")
-			continue
-		}
+// MultiHashStage - типизированный MultiHash без ограничений параллельности
+func MultiHashStage(ctx context.Context, in <-chan string, out chan<- string) error {
+	return MultiHashWith(StageOptions{})(ctx, in, out)
+}
 
-		wgExt.Add(1)
-		go func(data string, wgExt *sync.WaitGroup) {
-			defer wgExt.Done()
-
-			hashes := make(map[int]string)
-			mu := &sync.Mutex{}
-			wgInt := &sync.WaitGroup{}
-
-			for i := 0; i <= 5; i++ {
-				wgInt.Add(1)
-				go func(i int, data string, hashes map[int]string, mu *sync.Mutex, wg *sync.WaitGroup) {
-					defer wgInt.Done()
-					crc32Data := DataSignerCrc32(data)
-					fmt.Print(data, " MultiHash: crc32(th+data)) ", i, crc32Data, "\n")
-					mu.Lock()
-					hashes[i] = crc32Data
-					mu.Unlock()
-				}(i, fmt.Sprint(i)+data, hashes, mu, wgInt)
+// MultiHashWith возвращает MultiHash с ограничениями opts
+func MultiHashWith(opts StageOptions) Stage[string, string] {
+	return func(ctx context.Context, in <-chan string, out chan<- string) error {
+
+		pool := newWorkerPool(opts.Workers)
+		defer pool.stop()
+
+		return process(ctx, in, out, opts, func(data string) string {
+			hashes := make([]string, 6)
+			tasks := []func(){}
+			for i := range hashes {
+				tasks = append(tasks, func() {
+					hashes[i] = DataSignerCrc32(fmt.Sprint(i) + data)
+					fmt.Print(data, " MultiHash: crc32(th+data)) ", i, hashes[i], "\n")
+				})
 			}
-			wgInt.Wait()
+			pool.do(ctx, tasks...)
 
-			result := ""
-			for i := 0; i <= 5; i++ {
-				result += hashes[i]
-			}
+			result := strings.Join(hashes, "")
 			fmt.Print(data, " MultiHash result: ", result, "\n")
-			out <- result
-		}(data, wgExt)
+			return result
+		})
 	}
-	wgExt.Wait()
 }
 
 func CombineResults(in, out chan interface{}) {
+	assertStage("CombineResults", CombineResultsStage, logBadInput)(context.Background(), in, out)
+}
+
+// CombineResultsContext - CombineResults, который останавливается при отмене ctx
+// и возвращает ErrBadInput вместо пропуска значения
+func CombineResultsContext(ctx context.Context, in <-chan interface{}, out chan<- interface{}) error {
+	return assertStage("CombineResults", CombineResultsStage, failOnBadInput)(ctx, in, out)
+}
+
+// CombineResultsStage - типизированный CombineResults
+func CombineResultsStage(ctx context.Context, in <-chan string, out chan<- string) error {
 
 	storage := []string{}
 
-	for dataRaw := range in {
-		data, ok := dataRaw.(string)
+	for {
+		data, ok := receive(ctx, in)
 		if !ok {
-			log.Println("CombineResults: can't convert data to string")
-			continue
+			break
 		}
 		storage = append(storage, data)
 	}
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
 
 	sort.Strings(storage)
 
 	result := strings.Join(storage, "_")
 	fmt.Print("CombineResults ", result, "\n")
-	out <- result
+	return send(ctx, out, result)
 }
 
 func ExecutePipeline(jobs ...job) {
 
-	hooks := []chan interface{}{}
-	gate := make(chan struct{})
-
-	for _ = range jobs {
-		hooks = append(hooks, make(chan interface{}))
-	}
-
-	last := len(jobs) - 1
-
-	for i := 0; i < last; i++ {
-		go func(i int, in, out chan interface{}) {
-			jobs[i](in, out)
-			close(out)
-		}(i, hooks[i], hooks[i+1])
+	ctxJobs := []ctxJob{}
+	for _, j := range jobs {
+		ctxJobs = append(ctxJobs, FromJob(j))
 	}
 
-	go func(i int, in, out chan interface{}) {
-		jobs[i](in, out)
-		close(out)
-		gate <- struct{}{}
-		close(gate)
-	}(last, hooks[last], hooks[0])
-
-	for _ = range gate {
-	}
+	// обычные звенья не возвращают ошибок, а контекст никто не отменяет
+	ExecutePipelineContext(context.Background(), ctxJobs...)
 }
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// Stage - типизированное звено конвейера: читает In, пишет Out. Звено не закрывает
+// out, при отмене ctx прекращает работу и возвращает context.Cause(ctx).
+type Stage[In, Out any] func(ctx context.Context, in <-chan In, out chan<- Out) error
+
+// send отправляет значение дальше по конвейеру, если его не отменили
+func send[T any](ctx context.Context, out chan<- T, value T) error {
+	select {
+	case out <- value:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// receive получает следующее значение, ok == false - вход закрыт
+// или конвейер отменён
+func receive[T any](ctx context.Context, in <-chan T) (value T, ok bool) {
+	select {
+	case value, ok = <-in:
+		return value, ok
+	case <-ctx.Done():
+		return value, false
+	}
+}
+
+// drain вычитывает канал до закрытия, чтобы отправитель не завис
+func drain[T any](ch <-chan T) {
+	for range ch {
+	}
+}
+
+// Then соединяет два звена в одно, типы проверяются при компиляции.
+// Первая ошибка любого из звеньев отменяет другое и возвращается.
+func Then[A, B, C any](first Stage[A, B], second Stage[B, C]) Stage[A, C] {
+	return func(ctx context.Context, in <-chan A, out chan<- C) error {
+
+		ctx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+
+		mid := make(chan B)
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+			if err := first(ctx, in, mid); err != nil {
+				cancel(err)
+			}
+			close(mid)
+		}()
+
+		if err := second(ctx, mid, out); err != nil {
+			cancel(err)
+		}
+
+		// второе звено могло не дочитать вход, первое не должно зависнуть на отправке
+		drain(mid)
+		<-done
+
+		return context.Cause(ctx)
+	}
+}
+
+// Run подаёт inputs на вход звена и возвращает всё, что оно выдало
+func Run[In, Out any](ctx context.Context, stage Stage[In, Out], inputs ...In) ([]Out, error) {
+	results := []Out{}
+	err := run(ctx, stage, inputs, func(value Out) {
+		results = append(results, value)
+	})
+	return results, err
+}
+
+// run подаёт inputs на вход звена и передаёт его выход в sink
+func run[In, Out any](ctx context.Context, stage Stage[In, Out], inputs []In, sink func(Out)) error {
+
+	in, out := make(chan In), make(chan Out)
+	stop := make(chan struct{})
+	fed, collected := make(chan struct{}), make(chan struct{})
+
+	go func() {
+		defer close(fed)
+		defer close(in)
+		for _, value := range inputs {
+			select {
+			case in <- value:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer close(collected)
+		for value := range out {
+			sink(value)
+		}
+	}()
+
+	err := stage(ctx, in, out)
+
+	// звено могло не дочитать inputs
+	close(stop)
+	close(out)
+	<-fed
+	<-collected
+
+	return err
+}
+
+// assertStage превращает типизированное звено в звено на interface{}.
+// Значения не того типа передаются в badInput: если он вернёт ошибку,
+// конвейер остановится, иначе значение пропускается.
+func assertStage[In, Out any](name string, stage Stage[In, Out], badInput func(error) error) Stage[interface{}, interface{}] {
+
+	assertIn := func(ctx context.Context, in <-chan interface{}, out chan<- In) error {
+		for {
+			raw, ok := receive(ctx, in)
+			if !ok {
+				return context.Cause(ctx)
+			}
+			value, ok := raw.(In)
+			if !ok {
+				var expected In
+				if err := badInput(fmt.Errorf("%s: %w %T, expected %T", name, ErrBadInput, raw, expected)); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := send(ctx, out, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	toAny := func(ctx context.Context, in <-chan Out, out chan<- interface{}) error {
+		for {
+			value, ok := receive(ctx, in)
+			if !ok {
+				return context.Cause(ctx)
+			}
+			if err := send[interface{}](ctx, out, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return Then(Then(assertIn, stage), toAny)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func double(ctx context.Context, in <-chan int, out chan<- int) error {
+	for {
+		n, ok := receive(ctx, in)
+		if !ok {
+			return context.Cause(ctx)
+		}
+		if err := send(ctx, out, n*2); err != nil {
+			return err
+		}
+	}
+}
+
+func format(ctx context.Context, in <-chan int, out chan<- string) error {
+	for {
+		n, ok := receive(ctx, in)
+		if !ok {
+			return context.Cause(ctx)
+		}
+		if err := send(ctx, out, strconv.Itoa(n)); err != nil {
+			return err
+		}
+	}
+}
+
+func TestStageThen(t *testing.T) {
+	checkGoroutines(t)
+
+	// Then(format, double) не скомпилируется: double ждёт int, а format выдаёт string
+	pipeline := Then(Then(Stage[int, int](double), double), format)
+
+	result, err := Run(context.Background(), pipeline, 1, 2, 3)
+	expected := []string{"4", "8", "12"}
+	if err != nil || !reflect.DeepEqual(result, expected) {
+		t.Errorf("results not match\nGot: %v %v\nExpected: %v", result, err, expected)
+	}
+}
+
+func TestStageError(t *testing.T) {
+	checkGoroutines(t)
+
+	errTooBig := errors.New("too big")
+	limit := func(ctx context.Context, in <-chan int, out chan<- int) error {
+		for {
+			n, ok := receive(ctx, in)
+			if !ok {
+				return context.Cause(ctx)
+			}
+			if n > 4 {
+				return errTooBig
+			}
+			if err := send(ctx, out, n); err != nil {
+				return err
+			}
+		}
+	}
+
+	inputs := make([]int, 100)
+	for i := range inputs {
+		inputs[i] = i
+	}
+
+	result, err := Run(context.Background(), Then(Then(Stage[int, int](double), limit), format), inputs...)
+	if err != errTooBig {
+		t.Errorf("wrong error\nGot: %v\nExpected: %v", err, errTooBig)
+	}
+	expected := []string{"0", "2", "4"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("results not match\nGot: %v\nExpected: %v", result, expected)
+	}
+}
+
+func TestStageFromJob(t *testing.T) {
+	checkGoroutines(t)
+
+	squares := FromJob(func(in, out chan interface{}) {
+		for value := range in {
+			out <- value.(int) * value.(int)
+		}
+	})
+
+	result, err := Run(context.Background(), squares, 1, 2, 3)
+	expected := []interface{}{1, 4, 9}
+	if err != nil || !reflect.DeepEqual(result, expected) {
+		t.Errorf("results not match\nGot: %v %v\nExpected: %v", result, err, expected)
+	}
+}
+
+func TestStageSigner(t *testing.T) {
+	checkGoroutines(t)
+
+	pipeline := Then(Then(Stage[int, string](SingleHashStage), MultiHashStage), CombineResultsStage)
+
+	result, err := Run(context.Background(), pipeline, 0, 1)
+	expected := []string{"29568666068035183841425683795340791879727309630931025356555_4958044192186797981418233587017209679042592862002427381542"}
+	if err != nil || !reflect.DeepEqual(result, expected) {
+		t.Errorf("results not match\nGot: %v %v\nExpected: %v", result, err, expected)
+	}
+}
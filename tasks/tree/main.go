@@ -1,100 +1,670 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"html/template"
 	"io"
+	"io/fs"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/eunoia-meraki/tasting-go/tasks/tree/tree"
 )
 
-func dirTree(out io.Writer, path string, printFiles bool) error {
+const usage = "usage: tree [flags] [path ...]\n       tree --diff [flags] old new"
+
+// options - параметры библиотеки и флаги, которые есть только у команды
+type options struct {
+	tree.Options
+	noReport bool   // --noreport: не выводить итоговую строку
+	diff     bool   // --diff: сравнить два дерева
+	color    string // --color: auto, always или never
+	watch    bool   // --watch: перевыводить дерево после изменений
+	poll     time.Duration
+	debounce time.Duration
+	stats    bool // --stats: вывести сводку вместо дерева
+	top      int  // --top: сколько самых больших файлов показать в сводке
+	glyphs   string
+	indent   int
+	dupes    bool           // --dupes: отметить одинаковые файлы
+	grep     *regexp.Regexp // --grep: оставить только файлы с совпадениями
+	snapshot string         // --snapshot: записать снимок дерева в файл
+	verify   string         // --verify: сравнить дерево со снимком из файла
+	hash     bool           // --hash: записать в снимок SHA-256 файлов
+}
 
-	err := filepath.WalkDir(path,
-		func(walkPath string, entry os.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-
-			if path == walkPath {
-				return nil
-			}
-
-			if !printFiles && !entry.IsDir() {
-				return nil
-			}
-
-			relPath, err := filepath.Rel(path, walkPath)
-			if err != nil {
-				return err
-			}
-			split := strings.Split(relPath, string(os.PathSeparator))
-			targPath := path
-
-			for i := range split {
-				entries, err := os.ReadDir(targPath)
-				if err != nil {
-					return err
-				}
-
-				if !printFiles {
-					for !entries[len(entries)-1].IsDir() {
-						entries = entries[:len(entries)-1]
-					}
-				}
-
-				if i < len(split)-1 {
-					if split[i] == entries[len(entries)-1].Name() {
-						fmt.Fprint(out, "\t")
-					} else {
-						fmt.Fprint(out, "│\t")
-					}
-				} else {
-					if entry.Name() == entries[len(entries)-1].Name() {
-						fmt.Fprint(out, "└───")
-					} else {
-						fmt.Fprint(out, "├───")
-					}
-				}
-
-				targPath = targPath + string(os.PathSeparator) + split[i]
-			}
-
-			fmt.Fprint(out, entry.Name())
-
-			if !entry.IsDir() {
-				info, err := entry.Info()
-				if err != nil {
-					return err
-				}
-				if size := info.Size(); size != 0 {
-					fmt.Fprint(out, " (", size, "b)")
-				} else {
-					fmt.Fprint(out, " (empty)")
-				}
-			}
-
-			fmt.Fprint(out, "\n")
+// colorModes - допустимые значения --color
+var colorModes = []string{"auto", "always", "never"}
 
+// isTerminal - выводится ли out в терминал
+func isTerminal(out io.Writer) bool {
+	file, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// palette возвращает цвета для вывода в out или nil, если цвет не нужен
+func (opts options) palette(out io.Writer) tree.Palette {
+	switch opts.color {
+	case "never":
+		return nil
+	case "auto":
+		if !isTerminal(out) || os.Getenv("NO_COLOR") != "" {
 			return nil
-		})
+		}
+	}
+	return tree.ParseLSColors(os.Getenv("LS_COLORS"))
+}
+
+func dirTree(out io.Writer, path string, printFiles bool) error {
+	return tree.Render(out, os.DirFS(path), tree.Options{PrintFiles: printFiles})
+}
+
+// openRoot возвращает каталог path или содержимое архива, если path - архив
+func openRoot(path string) (fs.FS, error) {
+	if tree.IsArchive(path) {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return tree.OpenArchive(path)
+		}
+	}
+	return os.DirFS(path), nil
+}
 
+// relativeTo возвращает путь в ошибке os.DirFS к виду, который ввёл пользователь
+func relativeTo(err error, path string) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		pathErr.Path = filepath.Join(path, pathErr.Path)
+	}
+	return err
+}
+
+// buildTree читает дерево каталога или архива path, корень называется path.
+// fsys нужна тем, кто потом читает содержимое файлов дерева.
+func buildTree(path string, opts options) (root *tree.Node, fsys fs.FS, err error) {
+	fsys, err = openRoot(path)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
+	root, err = tree.Read(fsys, ".", opts.Options)
+	if err != nil {
+		return nil, nil, relativeTo(err, path)
+	}
+	root.Name = path
+	return root, fsys, nil
+}
 
+// buildDiff сравнивает деревья oldPath и newPath, корень называется "oldPath -> newPath"
+func buildDiff(oldPath, newPath string, opts options) (*tree.Node, error) {
+	roots := []fs.FS{}
+	for _, path := range []string{oldPath, newPath} {
+		fsys, err := openRoot(path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fs.Stat(fsys, "."); err != nil {
+			return nil, relativeTo(err, path)
+		}
+		roots = append(roots, fsys)
+	}
+	root, err := tree.Diff(roots[0], roots[1], opts.Options)
+	if err != nil {
+		return nil, err
+	}
+	root.Name = oldPath + " -> " + newPath
+	return root, nil
+}
+
+// diffReport - итоговая строка для --diff
+func diffReport(stats tree.DiffStats) string {
+	return fmt.Sprintf("%d added, %d removed, %d changed", stats.Added, stats.Removed, stats.Modified)
+}
+
+// treeStats - итоги для строки "N directories, M files"
+type treeStats struct {
+	dirs   int
+	files  int
+	errors int
+}
+
+func (s *treeStats) count(nodes []*tree.Node) {
+	for _, n := range nodes {
+		if n.IsDir {
+			s.dirs++
+		} else {
+			s.files++
+		}
+		if n.Err != nil {
+			s.errors++
+		}
+		s.count(n.Children)
+	}
+}
+
+func (s treeStats) report(printFiles bool) string {
+	result := tree.Plural(s.dirs, "directory", "directories")
+	if printFiles {
+		result += ", " + tree.Plural(s.files, "file", "files")
+	}
+	return result
+}
+
+// patterns - флаг, который можно указать несколько раз
+type patterns []string
+
+func (p *patterns) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *patterns) Set(value string) error {
+	if _, err := filepath.Match(value, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q", value)
+	}
+	*p = append(*p, value)
 	return nil
 }
 
-func main() {
-	out := os.Stdout
-	if !(len(os.Args) == 2 || len(os.Args) == 3) {
-		panic("usage go run main.go . [-f]")
+// parseArgs разбирает флаги и пути, флаги можно указывать и после путей,
+// как в "go run main.go . -f"
+func parseArgs(args []string, output io.Writer) ([]string, options, error) {
+
+	opts := options{color: "auto", glyphs: "unicode"}
+
+	flags := flag.NewFlagSet("tree", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprintln(output, usage)
+		flags.PrintDefaults()
+	}
+
+	flags.BoolVar(&opts.PrintFiles, "f", false, "print files")
+	flags.IntVar(&opts.MaxDepth, "L", 0, "max display depth, 0 means no limit")
+	flags.BoolVar(&opts.Prune, "prune", false, "hide directories without printable entries")
+	flags.Var((*patterns)(&opts.Include), "P", "list only files matching the pattern, may be repeated")
+	flags.Var((*patterns)(&opts.Exclude), "I", "hide files and directories matching the pattern, may be repeated")
+	flags.BoolVar(&opts.Gitignore, "gitignore", false, "honour .gitignore files")
+	flags.Func("format", "output format: text, json, xml, yaml or html", func(value string) error {
+		if _, ok := tree.Formatters[value]; !ok {
+			return fmt.Errorf("unknown format %q", value)
+		}
+		opts.Format = value
+		return nil
+	})
+	flags.Func("H", "HTML output with links relative to `baseURL`", func(value string) error {
+		opts.Format = "html"
+		opts.BaseURL = value
+		return nil
+	})
+	flags.Func("template", "html/template `file` for HTML output", func(value string) error {
+		tmpl, err := template.ParseFiles(value)
+		if err != nil {
+			return err
+		}
+		opts.HTMLTemplate = tmpl
+		return nil
+	})
+	flags.BoolVar(&opts.Human, "h", false, "print sizes in human readable units")
+	flags.BoolVar(&opts.DU, "du", false, "print cumulative sizes of directories")
+	flags.Func("sort", "sort by name, size or mtime", func(value string) error {
+		if !slices.Contains(tree.SortModes, value) {
+			return fmt.Errorf("unknown mode %q", value)
+		}
+		opts.SortBy = value
+		return nil
+	})
+	flags.BoolVar(&opts.DirsFirst, "dirsfirst", false, "list directories before files")
+	flags.BoolVar(&opts.Reverse, "r", false, "reverse the sort order")
+	flags.BoolVar(&opts.FollowLinks, "l", false, "follow symbolic links to directories")
+	flags.BoolVar(&opts.ShowMode, "p", false, "print permissions")
+	flags.BoolVar(&opts.ShowUser, "u", false, "print owner")
+	flags.BoolVar(&opts.ShowGroup, "g", false, "print group")
+	flags.BoolVar(&opts.ShowTime, "D", false, "print modification time")
+	flags.StringVar(&opts.TimeFormat, "timefmt", tree.DefaultTimeFormat, "time layout for -D")
+	flags.BoolVar(&opts.ShowInode, "inodes", false, "print inode numbers")
+	flags.BoolVar(&opts.noReport, "noreport", false, "omit the directory and file count")
+	flags.IntVar(&opts.Workers, "workers", 1, "number of directories and files read concurrently")
+	flags.Func("color", "colorize names using LS_COLORS: auto, always or never", func(value string) error {
+		if !slices.Contains(colorModes, value) {
+			return fmt.Errorf("unknown mode %q", value)
+		}
+		opts.color = value
+		return nil
+	})
+	flags.BoolFunc("C", "same as --color=always", func(string) error {
+		opts.color = "always"
+		return nil
+	})
+	flags.Func("glyphs", "connector characters: unicode, ascii or compact", func(value string) error {
+		if _, ok := tree.GlyphSets[value]; !ok {
+			return fmt.Errorf("unknown glyph set %q", value)
+		}
+		opts.glyphs = value
+		return nil
+	})
+	flags.IntVar(&opts.indent, "indent", 0, "indent width in columns, 0 means the glyph set default")
+	flags.BoolVar(&opts.dupes, "dupes", false, "mark files with identical contents and report wasted space")
+	flags.Func("grep", "show only files with lines matching the `regexp` and directories leading to them", func(value string) error {
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return err
+		}
+		opts.grep = re
+		return nil
+	})
+	flags.Int64Var(&opts.GrepMaxSize, "grep-max-size", 10<<20, "with --grep, skip files larger than this many bytes, 0 means no limit")
+	flags.StringVar(&opts.snapshot, "snapshot", "", "write paths, types and sizes to the JSON snapshot `file` instead of printing the tree")
+	flags.StringVar(&opts.verify, "verify", "", "compare the tree with the snapshot `file` and report differences")
+	flags.BoolVar(&opts.hash, "hash", false, "with --snapshot, record SHA-256 of every file")
+	flags.BoolVar(&opts.diff, "diff", false, "compare two trees: tree --diff OLD NEW")
+	flags.BoolVar(&opts.OnlyChanges, "only-changes", false, "with --diff, hide unchanged entries; with --watch, print only changes")
+	flags.BoolVar(&opts.CompareContent, "compare-content", false, "with --diff, compare contents of files of the same size")
+	flags.BoolVar(&opts.stats, "stats", false, "print per-extension counts and sizes, the largest files and the deepest path instead of the tree")
+	flags.IntVar(&opts.top, "top", 10, "with --stats, number of largest files to list")
+	flags.BoolVar(&opts.watch, "watch", false, "print the tree again whenever something changes")
+	flags.DurationVar(&opts.poll, "poll", 0, "with --watch, poll for changes at this interval instead of using inotify")
+	flags.DurationVar(&opts.debounce, "debounce", 200*time.Millisecond, "with --watch, wait this long for more changes before printing")
+
+	paths := []string{}
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, opts, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			break
+		}
+		paths = append(paths, args[0])
+		args = args[1:]
+	}
+
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	// ошибки проверки выводятся так же, как ошибки разбора во flag
+	var err error
+	switch {
+	case opts.MaxDepth < 0:
+		err = fmt.Errorf("invalid level %d", opts.MaxDepth)
+	case opts.diff && len(paths) != 2:
+		err = errors.New("--diff needs exactly two paths")
+	case opts.stats && (opts.diff || opts.watch || len(paths) != 1):
+		err = errors.New("--stats needs exactly one path")
+	case opts.stats && opts.Format != "" && opts.Format != "text" && opts.Format != "json":
+		err = fmt.Errorf("--stats supports text and json formats")
+	case opts.dupes && (opts.diff || opts.watch || opts.stats || len(paths) != 1):
+		err = errors.New("--dupes needs exactly one path")
+	case (opts.snapshot != "" || opts.verify != "") && (opts.diff || opts.watch || opts.stats || opts.dupes || len(paths) != 1):
+		err = errors.New("--snapshot and --verify need exactly one path")
+	case opts.snapshot != "" && opts.verify != "":
+		err = errors.New("--snapshot and --verify can't be combined")
+	case opts.hash && opts.snapshot == "":
+		err = errors.New("--hash needs --snapshot")
+	case opts.grep != nil && (opts.diff || opts.watch || opts.stats):
+		err = errors.New("--grep can't be combined with --diff, --watch or --stats")
+	case opts.GrepMaxSize < 0:
+		err = fmt.Errorf("invalid size %d", opts.GrepMaxSize)
+	case opts.indent < 0:
+		err = fmt.Errorf("invalid indent %d", opts.indent)
+	case opts.top < 0:
+		err = fmt.Errorf("invalid number of files %d", opts.top)
+	case opts.watch && (opts.diff || len(paths) != 1):
+		err = errors.New("--watch needs exactly one path")
+	case opts.OnlyChanges && !opts.diff && !opts.watch:
+		err = errors.New("--only-changes needs --diff or --watch")
+	case opts.CompareContent && !opts.diff:
+		err = errors.New("--compare-content needs --diff")
+	case opts.poll < 0 || opts.debounce < 0:
+		err = errors.New("invalid duration")
+	case len(paths) > 1 && !opts.diff && opts.Format != "" && opts.Format != "text":
+		err = fmt.Errorf("format %s supports a single path", opts.Format)
+	}
+	if err != nil {
+		fmt.Fprintln(output, err)
+		flags.Usage()
+		return nil, opts, err
+	}
+
+	return paths, opts, nil
+}
+
+// run выполняет команду и возвращает код выхода:
+// 0 - успех, 1 - ошибки чтения, 2 - неверные аргументы
+func run(args []string, stdout, stderr io.Writer) int {
+
+	paths, opts, err := parseArgs(args, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		return 2
+	}
+
+	// одинаковые файлы и совпадения ищутся среди файлов дерева, так что без -f они тоже нужны
+	if opts.dupes || opts.grep != nil {
+		opts.PrintFiles = true
+	}
+
+	opts.Colors = opts.palette(stdout)
+	style := tree.GlyphSets[opts.glyphs].Style(opts.indent)
+	opts.Style = &style
+
+	if opts.diff {
+		return runDiff(paths[0], paths[1], opts, stdout, stderr)
+	}
+
+	if opts.stats {
+		return runStats(paths[0], opts, stdout, stderr)
+	}
+
+	if opts.snapshot != "" {
+		return runSnapshot(paths[0], opts, stdout, stderr)
+	}
+
+	if opts.verify != "" {
+		return runVerify(paths[0], opts, stdout, stderr)
+	}
+
+	if opts.watch {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		return runWatch(ctx, paths[0], opts, stdout, stderr)
+	}
+
+	status := 0
+	stats := treeStats{}
+	text := opts.Format == "" || opts.Format == "text"
+
+	var dupes []tree.DupeGroup
+
+	for _, path := range paths {
+		root, fsys, err := buildTree(path, opts)
+		if err != nil {
+			fmt.Fprintln(stderr, "tree:", err)
+			status = 1
+			continue
+		}
+
+		if opts.dupes {
+			dupes = tree.FindDupes(fsys, root, opts.Workers)
+		}
+
+		if opts.grep != nil {
+			root = tree.Grep(fsys, root, opts.grep, opts.Options)
+		}
+
+		if text && len(paths) > 1 {
+			fmt.Fprintln(stdout, path)
+		}
+		if err := tree.Format(stdout, root, opts.Options); err != nil {
+			fmt.Fprintln(stderr, "tree:", err)
+			return 1
+		}
+
+		stats.count(root.Children)
+	}
+
+	if stats.errors > 0 {
+		fmt.Fprintln(stderr, "tree: some files or directories could not be read")
+		status = 1
+	}
+
+	if text && !opts.noReport {
+		fmt.Fprintf(stdout, "\n%s\n", stats.report(opts.PrintFiles))
 	}
-	path := os.Args[1]
-	printFiles := len(os.Args) == 3 && os.Args[2] == "-f"
-	err := dirTree(out, path, printFiles)
+
+	if text && opts.dupes {
+		fmt.Fprint(stdout, dupesReport(dupes))
+	}
+
+	return status
+}
+
+// dupesReport - список групп одинаковых файлов и итог для --dupes
+func dupesReport(groups []tree.DupeGroup) string {
+	b := &strings.Builder{}
+	var wasted int64
+	for _, g := range groups {
+		fmt.Fprintf(b, "dup %d (%db): %s\n", g.ID, g.Size, strings.Join(g.Paths, ", "))
+		wasted += g.Wasted()
+	}
+	fmt.Fprintf(b, "%s, %db wasted\n", tree.Plural(len(groups), "duplicate group", "duplicate groups"), wasted)
+	return b.String()
+}
+
+// runDiff выполняет tree --diff, коды выхода те же, что у run
+func runDiff(oldPath, newPath string, opts options, stdout, stderr io.Writer) int {
+
+	root, err := buildDiff(oldPath, newPath, opts)
+	if err != nil {
+		fmt.Fprintln(stderr, "tree:", err)
+		return 1
+	}
+
+	if err := tree.Format(stdout, root, opts.Options); err != nil {
+		fmt.Fprintln(stderr, "tree:", err)
+		return 1
+	}
+
+	status := 0
+	stats := treeStats{}
+	stats.count(root.Children)
+	if stats.errors > 0 {
+		fmt.Fprintln(stderr, "tree: some directories could not be read")
+		status = 1
+	}
+
+	if (opts.Format == "" || opts.Format == "text") && !opts.noReport {
+		fmt.Fprintf(stdout, "\n%s\n", diffReport(tree.CountDiff(root.Children)))
+	}
+
+	return status
+}
+
+// takeSnapshot читает дерево path со всеми файлами и записывает его снимок
+func takeSnapshot(path string, opts options, hashes bool) (tree.Snapshot, error) {
+	opts.PrintFiles = true
+	root, fsys, err := buildTree(path, opts)
+	if err != nil {
+		return tree.Snapshot{}, err
+	}
+	snapshot, err := tree.TakeSnapshot(fsys, root, hashes)
+	if err != nil {
+		return tree.Snapshot{}, relativeTo(err, path)
+	}
+	return snapshot, nil
+}
+
+// runSnapshot выполняет tree --snapshot, "-" - вывод снимка в stdout
+func runSnapshot(path string, opts options, stdout, stderr io.Writer) int {
+
+	snapshot, err := takeSnapshot(path, opts, opts.hash)
+	if err != nil {
+		fmt.Fprintln(stderr, "tree:", err)
+		return 1
+	}
+
+	if opts.snapshot == "-" {
+		err = snapshot.Write(stdout)
+	} else {
+		err = writeFile(opts.snapshot, snapshot.Write)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "tree:", err)
+		return 1
+	}
+	return 0
+}
+
+// writeFile создаёт файл name и записывает его через write
+func writeFile(name string, write func(io.Writer) error) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// runVerify выполняет tree --verify: выводит отличия от снимка и возвращает 1,
+// если они есть. Хеши сравниваются, если они записаны в снимке.
+func runVerify(path string, opts options, stdout, stderr io.Writer) int {
+
+	file, err := os.Open(opts.verify)
 	if err != nil {
-		panic(err.Error())
+		fmt.Fprintln(stderr, "tree:", err)
+		return 1
 	}
+	expected, err := tree.ReadSnapshot(file)
+	file.Close()
+	if err != nil {
+		fmt.Fprintf(stderr, "tree: %s: %v\n", opts.verify, err)
+		return 1
+	}
+
+	actual, err := takeSnapshot(path, opts, expected.Hashes)
+	if err != nil {
+		fmt.Fprintln(stderr, "tree:", err)
+		return 1
+	}
+
+	drift := expected.Verify(actual)
+	for _, d := range drift {
+		fmt.Fprintln(stdout, d)
+	}
+	if len(drift) > 0 {
+		fmt.Fprintf(stderr, "tree: %s differs from %s: %s\n", path, opts.verify, tree.Plural(len(drift), "difference", "differences"))
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "%s matches %s: %s\n", path, opts.verify, tree.Plural(len(actual.Entries), "entry", "entries"))
+	return 0
+}
+
+// runStats выполняет tree --stats: сводка считается по тому же обходу,
+// которым строится дерево, поэтому учитывает -L, -P, -I и --gitignore
+func runStats(path string, opts options, stdout, stderr io.Writer) int {
+
+	// файлы нужны в дереве, даже если без -f их не выводят
+	opts.PrintFiles = true
+
+	root, _, err := buildTree(path, opts)
+	if err != nil {
+		fmt.Fprintln(stderr, "tree:", err)
+		return 1
+	}
+
+	stats := tree.CollectStats(root, opts.top)
+	if opts.Format == "json" {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(stats)
+	} else {
+		err = stats.WriteText(stdout, opts.Human)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "tree:", err)
+		return 1
+	}
+
+	counts := treeStats{}
+	counts.count(root.Children)
+	if counts.errors > 0 {
+		fmt.Fprintln(stderr, "tree: some directories could not be read")
+		return 1
+	}
+	return 0
+}
+
+// defaultPollInterval - интервал опроса, если inotify недоступен
+const defaultPollInterval = time.Second
+
+// newWatcher возвращает inotify, если он доступен и не задан --poll, иначе опрос
+func newWatcher(path string, opts options) tree.Watcher {
+	if opts.poll == 0 {
+		if watcher, err := tree.NewNotifyWatcher(path); err == nil {
+			return watcher
+		}
+		opts.poll = defaultPollInterval
+	}
+	return tree.NewPollWatcher(os.DirFS(path), opts.poll)
+}
+
+// runWatch выполняет tree --watch до отмены ctx. С --only-changes после
+// первого вывода выводятся только отличия от предыдущего состояния.
+func runWatch(ctx context.Context, path string, opts options, stdout, stderr io.Writer) int {
+
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		if err == nil {
+			err = fmt.Errorf("%s is not a directory", path)
+		}
+		fmt.Fprintln(stderr, "tree:", err)
+		return 1
+	}
+
+	watcher := newWatcher(path, opts)
+	defer watcher.Close()
+
+	text := opts.Format == "" || opts.Format == "text"
+	clear := isTerminal(stdout) && !opts.OnlyChanges
+	var last *tree.Node
+
+	render := func() error {
+		// ошибка чтения не останавливает наблюдение: каталог могут пересоздать
+		root, _, err := buildTree(path, opts)
+		if err != nil {
+			fmt.Fprintln(stderr, "tree:", err)
+			return nil
+		}
+
+		shown, report := root, ""
+		if opts.OnlyChanges && last != nil {
+			shown = tree.DiffTrees(last, root, opts.Options)
+			report = diffReport(tree.CountDiff(shown.Children))
+		} else {
+			stats := treeStats{}
+			stats.count(root.Children)
+			report = stats.report(opts.PrintFiles)
+		}
+
+		if clear {
+			fmt.Fprint(stdout, "\x1b[H\x1b[2J")
+		} else if last != nil {
+			fmt.Fprintln(stdout)
+		}
+		last = root
+
+		if err := tree.Format(stdout, shown, opts.Options); err != nil {
+			return err
+		}
+		if text && !opts.noReport {
+			fmt.Fprintf(stdout, "\n%s\n", report)
+		}
+		return nil
+	}
+
+	if err := tree.Watch(ctx, watcher, opts.debounce, render); err != nil {
+		fmt.Fprintln(stderr, "tree:", err)
+		return 1
+	}
+	return 0
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
 }
@@ -1,10 +1,53 @@
 package main
 
 import (
+	"archive/zip"
 	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
+// makeTestdata создаёт во временном каталоге дерево из исходного задания
+// с теми же размерами файлов
+func makeTestdata(tb testing.TB) string {
+	tb.Helper()
+	files := fstest.MapFS{}
+	sizes := map[string]int{
+		"project/file.txt":                19,
+		"project/gopher.png":              70372,
+		"static/a_lorem/dolor.txt":        0,
+		"static/a_lorem/gopher.png":       70372,
+		"static/a_lorem/ipsum/gopher.png": 70372,
+		"static/css/body.css":             28,
+		"static/empty.txt":                0,
+		"static/html/index.html":          57,
+		"static/js/site.js":               10,
+		"static/z_lorem/dolor.txt":        0,
+		"static/z_lorem/gopher.png":       70372,
+		"static/z_lorem/ipsum/gopher.png": 70372,
+		"zline/empty.txt":                 0,
+		"zline/lorem/dolor.txt":           0,
+		"zline/lorem/gopher.png":          70372,
+		"zline/lorem/ipsum/gopher.png":    70372,
+		"zzfile.txt":                      0,
+	}
+	for name, size := range sizes {
+		files[name] = &fstest.MapFile{Data: make([]byte, size)}
+	}
+	root := tb.TempDir()
+	if err := os.CopyFS(root, files); err != nil {
+		tb.Fatal(err)
+	}
+	return root
+}
+
 const testFullResult = `├───project
 │	├───file.txt (19b)
 │	└───gopher.png (70372b)
@@ -73,3 +116,293 @@ func TestTreeDir(t *testing.T) {
 		t.Errorf("test for OK Failed - results not match\nGot:\n%v\nExpected:\n%v", result, testDirResult)
 	}
 }
+
+func TestRun(t *testing.T) {
+	t.Chdir(makeTestdata(t))
+	t.Setenv("LS_COLORS", "di=34")
+	t.Setenv("NO_COLOR", "")
+
+	archive, err := os.Create("release.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(archive)
+	for name, content := range map[string]string{"README.md": "# release", "bin/tool": "binary"} {
+		file, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	archive.Close()
+
+	cases := []struct {
+		args   []string
+		status int
+		stdout string
+		stderr string
+	}{
+		{
+			args:   []string{"zline", "-f"},
+			status: 0,
+			stdout: `├───empty.txt (empty)
+└───lorem
+	├───dolor.txt (empty)
+	├───gopher.png (70372b)
+	└───ipsum
+		└───gopher.png (70372b)
+
+2 directories, 4 files
+`,
+		},
+		{
+			args:   []string{"-L", "1", "zline", "project"},
+			status: 0,
+			stdout: `zline
+└───lorem
+project
+
+1 directory
+`,
+		},
+		{
+			args:   []string{"--noreport", "missing", "project", "-f"},
+			status: 1,
+			stdout: `project
+├───file.txt (19b)
+└───gopher.png (70372b)
+`,
+			stderr: "tree: open missing: no such file or directory\n",
+		},
+		{
+			args:   []string{"release.zip", "-f"},
+			status: 0,
+			stdout: `├───README.md (9b)
+└───bin
+	└───tool (6b)
+
+1 directory, 2 files
+`,
+		},
+		{
+			args:   []string{"--diff", "zline", "project", "-f", "-L", "1"},
+			status: 0,
+			stdout: `├───[-] empty.txt (empty)
+├───[+] file.txt (19b)
+├───[+] gopher.png (70372b)
+└───[-] lorem
+
+2 added, 2 removed, 0 changed
+`,
+		},
+		{
+			args:   []string{"--color=always", "-L", "1", "zline"},
+			status: 0,
+			stdout: "└───\x1b[34mlorem\x1b[0m\n\n1 directory\n",
+		},
+		{
+			args:   []string{"--color", "sometimes"},
+			status: 2,
+			stderr: `invalid value "sometimes" for flag -color: unknown mode "sometimes"`,
+		},
+		{
+			args:   []string{"-H", "https://example.com", "zline", "--template", "missing.tmpl"},
+			status: 2,
+			stderr: `invalid value "missing.tmpl" for flag -template: open missing.tmpl: no such file or directory`,
+		},
+		{
+			args:   []string{"--stats", "--top", "1", "project"},
+			status: 0,
+			stdout: `extension  files  size
+.png       1      70372b
+.txt       1      19b
+
+0 directories, 2 files, 0 empty, 70391b total
+deepest: file.txt (1 level)
+largest:
+  70372b  gopher.png
+`,
+		},
+		{
+			args:   []string{"--stats", "--format", "xml", "project"},
+			status: 2,
+			stderr: "--stats supports text and json formats\n",
+		},
+		{
+			args:   []string{"--glyphs", "ascii", "-f", "zline"},
+			status: 0,
+			stdout: "|-- empty.txt (empty)\n" +
+				"`-- lorem\n" +
+				"    |-- dolor.txt (empty)\n" +
+				"    |-- gopher.png (70372b)\n" +
+				"    `-- ipsum\n" +
+				"        `-- gopher.png (70372b)\n" +
+				"\n2 directories, 4 files\n",
+		},
+		{
+			args:   []string{"--dupes", "--workers", "2", "zline"},
+			status: 0,
+			stdout: `├───empty.txt (empty)
+└───lorem
+	├───dolor.txt (empty)
+	├───gopher.png (70372b) [dup 1]
+	└───ipsum
+		└───gopher.png (70372b) [dup 1]
+
+2 directories, 4 files
+dup 1 (70372b): lorem/gopher.png, lorem/ipsum/gopher.png
+1 duplicate group, 70372b wasted
+`,
+		},
+		{
+			args:   []string{"--grep", "(", "zline"},
+			status: 2,
+			stderr: `invalid value "(" for flag -grep: error parsing regexp: missing closing ): ` + "`(`\n",
+		},
+		{
+			args:   []string{"--snapshot", "zline.json", "zline"},
+			status: 0,
+		},
+		{
+			args:   []string{"--verify", "zline.json", "zline"},
+			status: 0,
+			stdout: "zline matches zline.json: 6 entries\n",
+		},
+		{
+			args:   []string{"--verify", "zline.json", "static/z_lorem", "-I", "ipsum"},
+			status: 1,
+			stdout: "unexpected: dolor.txt\n" +
+				"missing: empty.txt\n" +
+				"unexpected: gopher.png\n" +
+				"missing: lorem\n" +
+				"missing: lorem/dolor.txt\n" +
+				"missing: lorem/gopher.png\n" +
+				"missing: lorem/ipsum\n" +
+				"missing: lorem/ipsum/gopher.png\n",
+			stderr: "tree: static/z_lorem differs from zline.json: 8 differences\n",
+		},
+		{
+			args:   []string{"--diff", "zline"},
+			status: 2,
+			stderr: "--diff needs exactly two paths\n" + usage + "\n",
+		},
+		{
+			args:   []string{"-L", "-1"},
+			status: 2,
+			stderr: "invalid level -1\n" + usage + "\n",
+		},
+	}
+
+	for _, c := range cases {
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+		status := run(c.args, stdout, stderr)
+		if status != c.status {
+			t.Errorf("wrong status for %q\nGot: %d\nExpected: %d", c.args, status, c.status)
+		}
+		if stdout.String() != c.stdout {
+			t.Errorf("stdout not match for %q\nGot:\n%v\nExpected:\n%v", c.args, stdout, c.stdout)
+		}
+		if !strings.HasPrefix(stderr.String(), c.stderr) {
+			t.Errorf("stderr not match for %q\nGot:\n%v\nExpected:\n%v", c.args, stderr, c.stderr)
+		}
+	}
+}
+
+// outputs передаёт тесту каждый вывод, который runWatch делает из своей горутины
+type outputs chan string
+
+func (o outputs) Write(p []byte) (int, error) {
+	o <- string(p)
+	return len(p), nil
+}
+
+// readUntil собирает вывод, пока он не закончится строкой suffix
+func readUntil(t *testing.T, o outputs, suffix string) string {
+	t.Helper()
+	result := ""
+	for !strings.HasSuffix(result, suffix) {
+		select {
+		case s := <-o:
+			result += s
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no output ending with %q\nGot:\n%v", suffix, result)
+		}
+	}
+	return result
+}
+
+func TestRunWatch(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, opts, err := parseArgs([]string{"--watch", "--only-changes", "--poll", "10ms", "--debounce", "20ms", "-f", root}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stdout := make(outputs)
+	status := make(chan int)
+	go func() {
+		status <- runWatch(ctx, root, opts, stdout, io.Discard)
+	}()
+
+	got := readUntil(t, stdout, "1 file\n")
+	expected := "└───a.txt (1b)\n\n0 directories, 1 file\n"
+	if got != expected {
+		t.Errorf("first output not match\nGot:\n%v\nExpected:\n%v", got, expected)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "b.txt"), []byte("bb"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got = readUntil(t, stdout, "changed\n")
+	expected = "\n└───[+] b.txt (2b)\n\n1 added, 0 removed, 0 changed\n"
+	if got != expected {
+		t.Errorf("changes not match\nGot:\n%v\nExpected:\n%v", got, expected)
+	}
+
+	cancel()
+	if got := <-status; got != 0 {
+		t.Errorf("wrong status\nGot: %d\nExpected: %d", got, 0)
+	}
+}
+
+// makeFixture строит в root дерево глубины depth, где на каждом уровне
+// width каталогов и width файлов
+func makeFixture(tb testing.TB, root string, depth, width int) {
+	tb.Helper()
+	if depth == 0 {
+		return
+	}
+	for i := 0; i < width; i++ {
+		file := filepath.Join(root, fmt.Sprintf("file%d.txt", i))
+		if err := os.WriteFile(file, bytes.Repeat([]byte("x"), i), 0644); err != nil {
+			tb.Fatal(err)
+		}
+		dir := filepath.Join(root, fmt.Sprintf("dir%d", i))
+		if err := os.Mkdir(dir, 0755); err != nil {
+			tb.Fatal(err)
+		}
+		makeFixture(tb, dir, depth-1, width)
+	}
+}
+
+// -----
+// go test -bench . -benchmem
+
+func BenchmarkDirTree(b *testing.B) {
+	root := b.TempDir()
+	makeFixture(b, root, 5, 5)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := dirTree(io.Discard, root, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
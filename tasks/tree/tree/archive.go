@@ -0,0 +1,367 @@
+package tree
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// archiveFormats - поддерживаемые расширения архивов
+var archiveFormats = []string{".zip", ".tar", ".tar.gz", ".tgz"}
+
+// IsArchive проверяет по расширению, можно ли открыть файл через OpenArchive
+func IsArchive(name string) bool {
+	return archiveFormat(name) != ""
+}
+
+func archiveFormat(name string) string {
+	name = strings.ToLower(name)
+	for _, format := range archiveFormats {
+		if strings.HasSuffix(name, format) {
+			return format
+		}
+	}
+	return ""
+}
+
+// OpenArchive читает zip, tar или tar.gz архив в память и возвращает его
+// содержимое как fs.FS. Каталоги, для которых в архиве нет отдельных
+// записей, создаются по путям файлов.
+func OpenArchive(name string) (fs.FS, error) {
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch archiveFormat(name) {
+	case ".zip":
+		return readZip(data)
+	case ".tar":
+		return readTar(bytes.NewReader(data))
+	case ".tar.gz", ".tgz":
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		defer gz.Close()
+		return readTar(gz)
+	}
+
+	return nil, fmt.Errorf("%s: unknown archive format", name)
+}
+
+func readZip(data []byte) (fs.FS, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	fsys := newMemFS()
+	for _, file := range archive.File {
+		f := &memFile{
+			mode:    file.Mode(),
+			modTime: file.Modified,
+			size:    int64(file.UncompressedSize64),
+			open:    file.Open,
+		}
+		if f.mode&fs.ModeSymlink != 0 {
+			target, err := readAll(file.Open)
+			if err != nil {
+				return nil, err
+			}
+			f.target = string(target)
+		}
+		fsys.add(file.Name, f)
+	}
+
+	return fsys, nil
+}
+
+func readTar(r io.Reader) (fs.FS, error) {
+	archive := tar.NewReader(r)
+
+	fsys := newMemFS()
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		f := &memFile{
+			mode:    header.FileInfo().Mode(),
+			modTime: header.ModTime,
+			size:    header.Size,
+		}
+
+		switch header.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+			content, err := io.ReadAll(archive)
+			if err != nil {
+				return nil, err
+			}
+			f.open = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(content)), nil
+			}
+		case tar.TypeSymlink:
+			f.target = header.Linkname
+		case tar.TypeDir:
+		default:
+			// жёсткие ссылки, устройства и т.п. показываем как пустые файлы
+			f.mode = f.mode &^ fs.ModeType
+			f.size = 0
+		}
+
+		fsys.add(header.Name, f)
+	}
+
+	return fsys, nil
+}
+
+func readAll(open func() (io.ReadCloser, error)) ([]byte, error) {
+	r, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// memFS - дерево файлов архива в памяти
+type memFS struct {
+	files map[string]*memFile
+}
+
+// memFile - файл, каталог или ссылка внутри memFS
+type memFile struct {
+	name     string
+	mode     fs.FileMode
+	modTime  time.Time
+	size     int64
+	target   string                        // для ссылок
+	open     func() (io.ReadCloser, error) // для файлов
+	children []*memFile                    // для каталогов, по именам
+}
+
+func newMemFS() *memFS {
+	root := &memFile{name: ".", mode: fs.ModeDir | 0755}
+	return &memFS{files: map[string]*memFile{".": root}}
+}
+
+// add добавляет запись архива. Записи с некорректными путями пропускаются,
+// недостающие родительские каталоги создаются.
+func (m *memFS) add(name string, f *memFile) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" || !fs.ValidPath(name) {
+		return
+	}
+
+	f.name = path.Base(name)
+
+	if existing, ok := m.files[name]; ok {
+		// каталог уже создан по пути одного из файлов, уточняем его данные
+		if existing.mode.IsDir() && f.mode.IsDir() {
+			existing.mode, existing.modTime = f.mode, f.modTime
+		}
+		return
+	}
+
+	parent := m.dir(path.Dir(name))
+	m.files[name] = f
+	i, _ := slices.BinarySearchFunc(parent.children, f.name, func(child *memFile, name string) int {
+		return strings.Compare(child.name, name)
+	})
+	parent.children = slices.Insert(parent.children, i, f)
+}
+
+// dir возвращает каталог name, создавая его при необходимости
+func (m *memFS) dir(name string) *memFile {
+	if f, ok := m.files[name]; ok {
+		return f
+	}
+	f := &memFile{mode: fs.ModeDir | 0755}
+	m.add(name, f)
+	return f
+}
+
+// maxLinkHops - сколько ссылок раскрывается при поиске одного пути
+const maxLinkHops = 40
+
+// lookup находит файл, проходя путь по одному элементу и раскрывая ссылки
+// в промежуточных элементах, а с follow - и в последнем
+func (m *memFS) lookup(op, name string, follow bool) (*memFile, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	pending := splitPath(name)
+	dir := "."
+	hops := 0
+	for len(pending) > 0 {
+		current := path.Join(dir, pending[0])
+		pending = pending[1:]
+
+		f, ok := m.files[current]
+		if !ok {
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+
+		if f.mode&fs.ModeSymlink != 0 && (follow || len(pending) > 0) {
+			hops++
+			if hops > maxLinkHops {
+				return nil, &fs.PathError{Op: op, Path: name, Err: errors.New("too many links")}
+			}
+			target := resolveLink(dir, f.target)
+			if target == ".." || strings.HasPrefix(target, "../") || path.IsAbs(target) {
+				return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+			}
+			// в dir ссылок уже нет, поэтому .. в цели раскрыто верно,
+			// а ссылки внутри цели раскрываются по тем же правилам
+			pending = append(splitPath(target), pending...)
+			dir = "."
+			continue
+		}
+
+		if len(pending) > 0 && !f.mode.IsDir() {
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+		dir = current
+	}
+	return m.files[dir], nil
+}
+
+// splitPath разбивает корректный путь fs.FS на элементы, "." - пустой путь
+func splitPath(name string) []string {
+	if name == "." {
+		return nil
+	}
+	return strings.Split(name, "/")
+}
+
+func (m *memFS) Open(name string) (fs.File, error) {
+	f, err := m.lookup("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if f.mode.IsDir() {
+		return &memDir{file: f}, nil
+	}
+	var r io.ReadCloser = io.NopCloser(bytes.NewReader(nil))
+	if f.open != nil {
+		r, err = f.open()
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+	}
+	return &memReader{file: f, ReadCloser: r}, nil
+}
+
+func (m *memFS) Stat(name string) (fs.FileInfo, error) {
+	f, err := m.lookup("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return memInfo{f}, nil
+}
+
+func (m *memFS) Lstat(name string) (fs.FileInfo, error) {
+	f, err := m.lookup("lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+	return memInfo{f}, nil
+}
+
+func (m *memFS) ReadLink(name string) (string, error) {
+	f, err := m.lookup("readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	if f.mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return f.target, nil
+}
+
+func (m *memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	f, err := m.lookup("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if !f.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	entries := make([]fs.DirEntry, len(f.children))
+	for i, child := range f.children {
+		entries[i] = fs.FileInfoToDirEntry(memInfo{child})
+	}
+	return entries, nil
+}
+
+// memInfo реализует fs.FileInfo для memFile
+type memInfo struct {
+	file *memFile
+}
+
+func (i memInfo) Name() string       { return i.file.name }
+func (i memInfo) Size() int64        { return i.file.size }
+func (i memInfo) Mode() fs.FileMode  { return i.file.mode }
+func (i memInfo) ModTime() time.Time { return i.file.modTime }
+func (i memInfo) IsDir() bool        { return i.file.mode.IsDir() }
+func (i memInfo) Sys() any           { return nil }
+
+type memReader struct {
+	io.ReadCloser
+	file *memFile
+}
+
+func (r *memReader) Stat() (fs.FileInfo, error) {
+	return memInfo{r.file}, nil
+}
+
+// memDir - открытый каталог, ReadDir выдаёт элементы порциями
+type memDir struct {
+	file   *memFile
+	offset int
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) {
+	return memInfo{d.file}, nil
+}
+
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.file.name, Err: errors.New("is a directory")}
+}
+
+func (d *memDir) Close() error {
+	return nil
+}
+
+func (d *memDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	rest := d.file.children[d.offset:]
+	if count > 0 && len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if count > 0 && count < len(rest) {
+		rest = rest[:count]
+	}
+	d.offset += len(rest)
+	entries := make([]fs.DirEntry, len(rest))
+	for i, child := range rest {
+		entries[i] = fs.FileInfoToDirEntry(memInfo{child})
+	}
+	return entries, nil
+}
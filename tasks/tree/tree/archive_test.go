@@ -0,0 +1,239 @@
+package tree
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// archiveEntry - запись тестового архива, имя с "/" на конце - каталог
+type archiveEntry struct {
+	name    string
+	content string
+	link    string
+}
+
+var testArchiveEntries = []archiveEntry{
+	{name: "release/"},
+	{name: "release/README.md", content: "# release"},
+	{name: "release/bin/tool", content: "binary"},
+	{name: "release/docs/empty.txt"},
+	{name: "release/latest", link: "bin/tool"},
+	{name: "./release/docs/guide/index.html", content: "<html></html>"},
+}
+
+const testArchiveResult = `└───release
+	├───README.md (9b)
+	├───bin
+	│	└───tool (6b)
+	├───docs
+	│	├───empty.txt (empty)
+	│	└───guide
+	│		└───index.html (13b)
+	└───latest -> bin/tool (6b)
+`
+
+func writeTar(t *testing.T, w io.Writer, entries []archiveEntry) {
+	t.Helper()
+	archive := tar.NewWriter(w)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0644, ModTime: time.Unix(1600000000, 0)}
+		switch {
+		case entry.link != "":
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = entry.link
+		case entry.name[len(entry.name)-1] == '/':
+			header.Typeflag = tar.TypeDir
+			header.Mode = 0755
+		default:
+			header.Typeflag = tar.TypeReg
+			header.Size = int64(len(entry.content))
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeZip(t *testing.T, w io.Writer, entries []archiveEntry) {
+	t.Helper()
+	archive := zip.NewWriter(w)
+	for _, entry := range entries {
+		// каталоги в zip не пишем, они должны появиться по путям файлов
+		if entry.content == "" && entry.link == "" && entry.name[len(entry.name)-1] == '/' {
+			continue
+		}
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		content := entry.content
+		if entry.link != "" {
+			header.SetMode(fs.ModeSymlink | 0777)
+			content = entry.link
+		}
+		file, err := archive.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// archiveWriters - способы записать тестовый архив по имени файла
+var archiveWriters = map[string]func(t *testing.T, w io.Writer, entries []archiveEntry){
+	"release.tar": writeTar,
+	"release.zip": writeZip,
+	"release.tar.gz": func(t *testing.T, w io.Writer, entries []archiveEntry) {
+		gz := gzip.NewWriter(w)
+		writeTar(t, gz, entries)
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	},
+}
+
+func TestOpenArchive(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, write := range archiveWriters {
+		buf := new(bytes.Buffer)
+		write(t, buf, testArchiveEntries)
+		archivePath := filepath.Join(dir, name)
+		if err := os.WriteFile(archivePath, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if !IsArchive(archivePath) {
+			t.Errorf("%s is not recognized as archive", name)
+		}
+
+		fsys, err := OpenArchive(archivePath)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+
+		err = fstest.TestFS(fsys, "release/README.md", "release/bin/tool", "release/docs/empty.txt", "release/docs/guide/index.html")
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+
+		content, err := fs.ReadFile(fsys, "release/latest")
+		if err != nil || string(content) != "binary" {
+			t.Errorf("%s: wrong link content %q, %v", name, content, err)
+		}
+
+		out := new(bytes.Buffer)
+		if err := Render(out, fsys, Options{PrintFiles: true}); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if result := out.String(); result != testArchiveResult {
+			t.Errorf("%s: results not match\nGot:\n%v\nExpected:\n%v", name, result, testArchiveResult)
+		}
+	}
+}
+
+func TestOpenArchiveFollowLinks(t *testing.T) {
+	entries := []archiveEntry{
+		{name: "a/b/c.txt", content: "c"},
+		{name: "a/b/up", link: ".."},
+		{name: "a/top.txt", content: "top"},
+		{name: "d/deep", link: "link/b"},
+		{name: "d/link", link: "../a"},
+	}
+
+	// то же дерево на диске
+	dir := t.TempDir()
+	for _, entry := range entries {
+		file := filepath.Join(dir, "disk", filepath.FromSlash(entry.name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		var err error
+		if entry.link != "" {
+			err = os.Symlink(entry.link, file)
+		} else {
+			err = os.WriteFile(file, []byte(entry.content), 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := Options{PrintFiles: true, FollowLinks: true}
+	expected := new(bytes.Buffer)
+	if err := Render(expected, os.DirFS(filepath.Join(dir, "disk")), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, write := range archiveWriters {
+		buf := new(bytes.Buffer)
+		write(t, buf, entries)
+		archivePath := filepath.Join(dir, name)
+		if err := os.WriteFile(archivePath, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		fsys, err := OpenArchive(archivePath)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		// ссылки в середине пути раскрываются так же, как в конце
+		for file, content := range map[string]string{
+			"d/link/b/c.txt":           "c",
+			"d/deep/c.txt":             "c",
+			"a/b/up/top.txt":           "top",
+			"d/link/b/up/b/up/top.txt": "top",
+		} {
+			data, err := fs.ReadFile(fsys, file)
+			if err != nil || string(data) != content {
+				t.Errorf("%s: wrong content of %s\nGot:\n%q %v\nExpected:\n%q", name, file, data, err, content)
+			}
+		}
+
+		out := new(bytes.Buffer)
+		if err := Render(out, fsys, opts); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if out.String() != expected.String() {
+			t.Errorf("%s: results not match\nGot:\n%v\nExpected:\n%v", name, out, expected)
+		}
+	}
+}
+
+func TestOpenArchiveErrors(t *testing.T) {
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken.tar.gz")
+	if err := os.WriteFile(broken, []byte("not a gzip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := OpenArchive(broken); err == nil {
+		t.Errorf("expected error for broken archive")
+	}
+	if _, err := OpenArchive(filepath.Join(dir, "missing.zip")); err == nil {
+		t.Errorf("expected error for missing archive")
+	}
+	if IsArchive("notes.txt") {
+		t.Errorf("notes.txt is recognized as archive")
+	}
+}
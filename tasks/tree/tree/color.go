@@ -0,0 +1,100 @@
+package tree
+
+import (
+	"io/fs"
+	"strings"
+)
+
+// Palette - цвета в формате LS_COLORS: ключи di, ln, or, ex, fi, pi, so, bd, cd
+// и шаблоны расширений вида *.go, значения - параметры SGR, например 01;34
+type Palette map[string]string
+
+// DefaultPalette - цвета, если LS_COLORS не задан, как у dircolors по умолчанию
+var DefaultPalette = Palette{
+	"di":       "01;34",
+	"ln":       "01;36",
+	"or":       "40;31;01",
+	"ex":       "01;32",
+	"pi":       "40;33",
+	"so":       "01;35",
+	"bd":       "40;33;01",
+	"cd":       "40;33;01",
+	"*.tar":    "01;31",
+	"*.tgz":    "01;31",
+	"*.gz":     "01;31",
+	"*.zip":    "01;31",
+	"*.png":    "01;35",
+	"*.jpg":    "01;35",
+	"*.gif":    "01;35",
+	"*.svg":    "01;35",
+	"*.mp3":    "00;36",
+	"*.flac":   "00;36",
+	"*.wav":    "00;36",
+	"*.tar.gz": "01;31",
+}
+
+// ParseLSColors разбирает значение переменной LS_COLORS поверх DefaultPalette.
+// Некорректные записи пропускаются, как это делает ls.
+func ParseLSColors(value string) Palette {
+	p := Palette{}
+	for key, color := range DefaultPalette {
+		p[key] = color
+	}
+	for _, entry := range strings.Split(value, ":") {
+		key, color, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			continue
+		}
+		if color == "" || color == "0" || color == "00" {
+			delete(p, key)
+			continue
+		}
+		p[key] = color
+	}
+	return p
+}
+
+// colorOf возвращает параметры SGR для элемента, пустую строку - без цвета
+func (p Palette) colorOf(n *Node) string {
+	switch {
+	case n.Broken:
+		if color, ok := p["or"]; ok {
+			return color
+		}
+		return p["ln"]
+	case n.LinkTarget != "":
+		return p["ln"]
+	case n.IsDir:
+		return p["di"]
+	case n.Mode&fs.ModeNamedPipe != 0:
+		return p["pi"]
+	case n.Mode&fs.ModeSocket != 0:
+		return p["so"]
+	case n.Mode&fs.ModeCharDevice != 0:
+		return p["cd"]
+	case n.Mode&fs.ModeDevice != 0:
+		return p["bd"]
+	case n.Mode&0111 != 0:
+		if color, ok := p["ex"]; ok {
+			return color
+		}
+	}
+
+	// самый длинный подходящий шаблон, чтобы *.tar.gz был важнее *.gz
+	color, longest := p["fi"], 0
+	for key, value := range p {
+		if strings.HasPrefix(key, "*") && len(key) > longest && strings.HasSuffix(n.Name, key[1:]) {
+			color, longest = value, len(key)
+		}
+	}
+	return color
+}
+
+// paint возвращает имя элемента, окрашенное по палитре
+func (p Palette) paint(n *Node) string {
+	color := p.colorOf(n)
+	if color == "" {
+		return n.Name
+	}
+	return "\x1b[" + color + "m" + n.Name + "\x1b[0m"
+}
@@ -0,0 +1,58 @@
+package tree
+
+import (
+	"bytes"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseLSColors(t *testing.T) {
+	p := ParseLSColors("di=00;34:*.go=32:ln=0:broken:*.tar.gz=31")
+
+	expected := map[string]string{
+		"di":       "00;34",
+		"*.go":     "32",
+		"ln":       "",
+		"broken":   "",
+		"*.tar.gz": "31",
+		"ex":       DefaultPalette["ex"],
+	}
+	for key, color := range expected {
+		if p[key] != color {
+			t.Errorf("color of %q not match\nGot:\n%v\nExpected:\n%v", key, p[key], color)
+		}
+	}
+}
+
+func TestTreeColor(t *testing.T) {
+	fsys := fstest.MapFS{
+		"bin":         {Mode: fs.ModeDir},
+		"bin/run":     {Data: []byte("#!/bin/sh"), Mode: 0755},
+		"dist.tar.gz": {Data: []byte("gz")},
+		"main.go":     {Data: []byte("package main")},
+		"notes.txt":   {Data: []byte("notes")},
+		"current":     {Data: []byte("bin"), Mode: fs.ModeSymlink},
+		"missing":     {Data: []byte("nowhere"), Mode: fs.ModeSymlink},
+	}
+	opts := Options{
+		PrintFiles: true,
+		Colors:     ParseLSColors("di=34:ex=32:ln=36:or=31:*.go=33:*.gz=35:*.tar.gz=91"),
+	}
+
+	out := new(bytes.Buffer)
+	if err := Render(out, fsys, opts); err != nil {
+		t.Fatalf("test for OK Failed - error: %v", err)
+	}
+
+	expected := "├───\x1b[34mbin\x1b[0m\n" +
+		"│\t└───\x1b[32mrun\x1b[0m (9b)\n" +
+		"├───\x1b[36mcurrent\x1b[0m -> bin\n" +
+		"├───\x1b[91mdist.tar.gz\x1b[0m (2b)\n" +
+		"├───\x1b[33mmain.go\x1b[0m (12b)\n" +
+		"├───\x1b[31mmissing\x1b[0m -> nowhere\n" +
+		"└───notes.txt (5b)\n"
+	if out.String() != expected {
+		t.Errorf("test for OK Failed - results not match\nGot:\n%q\nExpected:\n%q", out, expected)
+	}
+}
@@ -0,0 +1,231 @@
+package tree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+)
+
+// DiffStatus - отличие элемента при сравнении двух деревьев
+type DiffStatus int
+
+const (
+	Unchanged DiffStatus = iota
+	Added
+	Removed
+	Modified // у файла изменился размер или содержимое
+)
+
+func (s DiffStatus) String() string {
+	switch s {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	}
+	return "unchanged"
+}
+
+// Diff читает оба дерева с одними и теми же opts и сливает их в одно,
+// где у каждого элемента задан Status. Файлы одного размера сравниваются
+// по SHA-256 содержимого, только если включён opts.CompareContent.
+func Diff(oldFS, newFS fs.FS, opts Options) (*Node, error) {
+
+	oldTree, err := Read(oldFS, ".", opts)
+	if err != nil {
+		return nil, err
+	}
+	newTree, err := Read(newFS, ".", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	d := differ{oldFS: oldFS, newFS: newFS, opts: opts}
+	children, _, err := d.merge(".", oldTree.Children, newTree.Children)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Node{Name: ".", IsDir: true, Children: children}, nil
+}
+
+// DiffTrees сливает два уже прочитанных дерева так же, как Diff, но без
+// сравнения содержимого. Исходные деревья не изменяются.
+func DiffTrees(oldTree, newTree *Node, opts Options) *Node {
+	opts.CompareContent = false
+	children, _, _ := differ{opts: opts}.merge(".", oldTree.Children, newTree.Children)
+	return &Node{Name: newTree.Name, IsDir: true, Children: children}
+}
+
+type differ struct {
+	oldFS fs.FS
+	newFS fs.FS
+	opts  Options
+}
+
+// merge сливает элементы каталога dir из двух деревьев,
+// changed - есть ли среди них отличия
+func (d differ) merge(dir string, oldNodes, newNodes []*Node) ([]*Node, bool, error) {
+
+	// сливать удобнее по именам, порядок вывода задаётся в конце
+	oldNodes, newNodes = byName(oldNodes), byName(newNodes)
+
+	result := []*Node{}
+	changed := false
+	i, j := 0, 0
+
+	for i < len(oldNodes) || j < len(newNodes) {
+		var n *Node
+		var err error
+		nodeChanged := true
+
+		switch {
+		case j == len(newNodes) || i < len(oldNodes) && oldNodes[i].Name < newNodes[j].Name:
+			n = markAll(oldNodes[i], Removed)
+			i++
+		case i == len(oldNodes) || newNodes[j].Name < oldNodes[i].Name:
+			n = markAll(newNodes[j], Added)
+			j++
+		case oldNodes[i].IsDir != newNodes[j].IsDir:
+			// файл стал каталогом или наоборот - это удаление и добавление
+			result = append(result, markAll(oldNodes[i], Removed))
+			n = markAll(newNodes[j], Added)
+			i++
+			j++
+		default:
+			n, nodeChanged, err = d.compare(path.Join(dir, newNodes[j].Name), oldNodes[i], newNodes[j])
+			if err != nil {
+				return nil, false, err
+			}
+			i++
+			j++
+		}
+
+		changed = changed || nodeChanged
+		if d.opts.OnlyChanges && !nodeChanged {
+			continue
+		}
+		result = append(result, n)
+	}
+
+	sortNodes(result, d.opts)
+
+	return result, changed, nil
+}
+
+// compare сравнивает элемент, который есть в обоих деревьях
+func (d differ) compare(name string, oldNode, newNode *Node) (*Node, bool, error) {
+
+	n := copyNode(newNode)
+	n.OldSize = oldNode.Size
+
+	if n.IsDir {
+		children, changed, err := d.merge(name, oldNode.Children, newNode.Children)
+		if err != nil {
+			return nil, false, err
+		}
+		n.Children = children
+		return n, changed, nil
+	}
+
+	if oldNode.LinkTarget != newNode.LinkTarget || oldNode.Size != newNode.Size {
+		n.Status = Modified
+		return n, true, nil
+	}
+
+	if d.opts.CompareContent && !n.Broken {
+		same, err := sameContent(d.oldFS, d.newFS, name)
+		if err != nil {
+			return nil, false, err
+		}
+		if !same {
+			n.Status = Modified
+			return n, true, nil
+		}
+	}
+
+	return n, false, nil
+}
+
+// markAll возвращает копию элемента, в которой отмечено всё поддерево
+func markAll(n *Node, status DiffStatus) *Node {
+	marked := copyNode(n)
+	marked.Status = status
+	marked.Children = nil
+	for _, child := range n.Children {
+		marked.Children = append(marked.Children, markAll(child, status))
+	}
+	return marked
+}
+
+func copyNode(n *Node) *Node {
+	c := *n
+	return &c
+}
+
+// byName возвращает копию списка, упорядоченную по имени
+func byName(nodes []*Node) []*Node {
+	sorted := slices.Clone(nodes)
+	sortNodes(sorted, Options{})
+	return sorted
+}
+
+func sameContent(oldFS, newFS fs.FS, name string) (bool, error) {
+	oldSum, err := hashFile(oldFS, name)
+	if err != nil {
+		return false, err
+	}
+	newSum, err := hashFile(newFS, name)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(oldSum, newSum), nil
+}
+
+// hashFile считает SHA-256 файла, не читая его в память целиком
+func hashFile(fsys fs.FS, name string) ([]byte, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+// DiffStats - число добавленных, удалённых и изменённых элементов
+type DiffStats struct {
+	Added    int
+	Removed  int
+	Modified int
+}
+
+// CountDiff подсчитывает отличия в дереве, которое вернул Diff
+func CountDiff(nodes []*Node) DiffStats {
+	stats := DiffStats{}
+	var count func(nodes []*Node)
+	count = func(nodes []*Node) {
+		for _, n := range nodes {
+			switch n.Status {
+			case Added:
+				stats.Added++
+			case Removed:
+				stats.Removed++
+			case Modified:
+				stats.Modified++
+			}
+			count(n.Children)
+		}
+	}
+	count(nodes)
+	return stats
+}
@@ -0,0 +1,136 @@
+package tree
+
+import (
+	"bytes"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func diffTestFS() (fstest.MapFS, fstest.MapFS) {
+	oldFS := fstest.MapFS{
+		"docs":           {Mode: fs.ModeDir},
+		"docs/guide.md":  {Data: []byte("guide")},
+		"docs/notes.txt": {Data: []byte("notes")},
+		"old":            {Mode: fs.ModeDir},
+		"old/legacy.go":  {Data: []byte("package old")},
+		"same.txt":       {Data: []byte("same")},
+		"swap":           {Data: []byte("file")},
+		"version.txt":    {Data: []byte("v1.0")},
+		"main.go":        {Data: []byte("package main")},
+	}
+	newFS := fstest.MapFS{
+		"docs":           {Mode: fs.ModeDir},
+		"docs/guide.md":  {Data: []byte("guide, longer")},
+		"docs/notes.txt": {Data: []byte("notes")},
+		"new":            {Mode: fs.ModeDir},
+		"new/fresh.go":   {Data: []byte("package new")},
+		"same.txt":       {Data: []byte("same")},
+		"swap":           {Mode: fs.ModeDir},
+		"swap/inner":     {Data: []byte("x")},
+		"version.txt":    {Data: []byte("v2.0")},
+		"main.go":        {Data: []byte("package main")},
+	}
+	return oldFS, newFS
+}
+
+func TestDiff(t *testing.T) {
+	oldFS, newFS := diffTestFS()
+
+	cases := []struct {
+		opts     Options
+		expected string
+		stats    DiffStats
+	}{
+		{
+			opts: Options{PrintFiles: true},
+			expected: `├───docs
+│	├───[~] guide.md (5b -> 13b)
+│	└───notes.txt (5b)
+├───main.go (12b)
+├───[+] new
+│	└───[+] fresh.go (11b)
+├───[-] old
+│	└───[-] legacy.go (11b)
+├───same.txt (4b)
+├───[-] swap (4b)
+├───[+] swap
+│	└───[+] inner (1b)
+└───version.txt (4b)
+`,
+			stats: DiffStats{Added: 4, Removed: 3, Modified: 1},
+		},
+		{
+			opts: Options{PrintFiles: true, OnlyChanges: true, CompareContent: true},
+			expected: `├───docs
+│	└───[~] guide.md (5b -> 13b)
+├───[+] new
+│	└───[+] fresh.go (11b)
+├───[-] old
+│	└───[-] legacy.go (11b)
+├───[-] swap (4b)
+├───[+] swap
+│	└───[+] inner (1b)
+└───[~] version.txt (4b)
+`,
+			stats: DiffStats{Added: 4, Removed: 3, Modified: 2},
+		},
+		{
+			opts: Options{OnlyChanges: true},
+			expected: `├───[+] new
+├───[-] old
+└───[+] swap
+`,
+			stats: DiffStats{Added: 2, Removed: 1},
+		},
+	}
+
+	for _, c := range cases {
+		root, err := Diff(oldFS, newFS, c.opts)
+		if err != nil {
+			t.Fatalf("diff failed with %+v: %v", c.opts, err)
+		}
+		out := new(bytes.Buffer)
+		if err := Format(out, root, c.opts); err != nil {
+			t.Fatalf("format failed: %v", err)
+		}
+		if out.String() != c.expected {
+			t.Errorf("diff not match with %+v\nGot:\n%v\nExpected:\n%v", c.opts, out, c.expected)
+		}
+		if stats := CountDiff(root.Children); stats != c.stats {
+			t.Errorf("stats not match with %+v\nGot:\n%v\nExpected:\n%v", c.opts, stats, c.stats)
+		}
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	oldFS := fstest.MapFS{"a.txt": {Data: []byte("a")}}
+	newFS := fstest.MapFS{"a.txt": {Data: []byte("aa")}}
+
+	root, err := Diff(oldFS, newFS, Options{PrintFiles: true, Format: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(bytes.Buffer)
+	if err := Format(out, root, Options{PrintFiles: true, Format: "json"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{
+  "name": ".",
+  "type": "directory",
+  "children": [
+    {
+      "name": "a.txt",
+      "type": "file",
+      "size": 2,
+      "old_size": 1,
+      "status": "modified"
+    }
+  ]
+}
+`
+	if out.String() != expected {
+		t.Errorf("json not match\nGot:\n%v\nExpected:\n%v", out, expected)
+	}
+}
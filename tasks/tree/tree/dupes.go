@@ -0,0 +1,115 @@
+package tree
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+)
+
+// DupeGroup - файлы с одинаковым содержимым, ID совпадает с Node.Dupe
+type DupeGroup struct {
+	ID    int      `json:"id"`
+	Size  int64    `json:"size"`
+	Paths []string `json:"paths"`
+}
+
+// Wasted - сколько байт занимают копии сверх одного экземпляра
+func (g DupeGroup) Wasted() int64 {
+	return g.Size * int64(len(g.Paths)-1)
+}
+
+// dupeCandidate - файл дерева и его путь внутри fs.FS
+type dupeCandidate struct {
+	path string
+	node *Node
+	sum  string
+	err  error
+}
+
+// FindDupes ищет в дереве, прочитанном из fsys с PrintFiles, файлы с одинаковым
+// содержимым и отмечает их в Node.Dupe. Сначала файлы группируются по размеру,
+// SHA-256 считается только у файлов с общим размером, не больше workers файлов
+// одновременно. Пустые файлы и ссылки не учитываются. Группы упорядочены
+// по убыванию размера. Файл, который не удалось прочитать, пропускается,
+// а ошибка записывается в его Node.Err.
+func FindDupes(fsys fs.FS, root *Node, workers int) []DupeGroup {
+
+	bySize := map[int64][]*dupeCandidate{}
+	var collect func(nodes []*Node, dir string)
+	collect = func(nodes []*Node, dir string) {
+		for _, n := range nodes {
+			name := path.Join(dir, n.Name)
+			if n.IsDir {
+				collect(n.Children, name)
+				continue
+			}
+			if n.LinkTarget == "" && n.Size > 0 {
+				bySize[n.Size] = append(bySize[n.Size], &dupeCandidate{path: name, node: n})
+			}
+		}
+	}
+	collect(root.Children, "")
+
+	candidates := []*dupeCandidate{}
+	for _, files := range bySize {
+		if len(files) > 1 {
+			candidates = append(candidates, files...)
+		}
+	}
+
+	p := newPool(workers)
+	wg := &sync.WaitGroup{}
+	for _, c := range candidates {
+		p.run(wg, func() {
+			var sum []byte
+			sum, c.err = hashFile(fsys, c.path)
+			c.sum = string(sum)
+		})
+	}
+	wg.Wait()
+
+	type groupKey struct {
+		size int64
+		sum  string
+	}
+	byHash := map[groupKey][]*dupeCandidate{}
+	for _, c := range candidates {
+		if c.err != nil {
+			c.node.Err = c.err
+			continue
+		}
+		key := groupKey{c.node.Size, c.sum}
+		byHash[key] = append(byHash[key], c)
+	}
+
+	groups := []DupeGroup{}
+	members := map[string][]*dupeCandidate{}
+	for key, files := range byHash {
+		if len(files) < 2 {
+			continue
+		}
+		sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+		g := DupeGroup{Size: key.size}
+		for _, f := range files {
+			g.Paths = append(g.Paths, f.path)
+		}
+		groups = append(groups, g)
+		members[g.Paths[0]] = files
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Size != groups[j].Size {
+			return groups[i].Size > groups[j].Size
+		}
+		return groups[i].Paths[0] < groups[j].Paths[0]
+	})
+	for i := range groups {
+		groups[i].ID = i + 1
+		for _, f := range members[groups[i].Paths[0]] {
+			f.node.Dupe = groups[i].ID
+		}
+	}
+
+	return groups
+}
@@ -0,0 +1,121 @@
+package tree
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFindDupes(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"a/logo.png":     "PNG image",
+		"b/logo-old.png": "PNG image",
+		"b/copy.txt":     "hello",
+		"c/hello.txt":    "hello",
+		"c/other.txt":    "world",
+		"c/same-size":    "PNG imagf",
+		"empty1":         "",
+		"empty2":         "",
+		"hello.txt":      "hello",
+	}
+	for name, content := range files {
+		file := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("hello.txt", filepath.Join(root, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, workers := range []int{1, 4} {
+		fsys := os.DirFS(root)
+		opts := Options{PrintFiles: true}
+		tree, err := Read(fsys, ".", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		groups := FindDupes(fsys, tree, workers)
+
+		expected := []DupeGroup{
+			{ID: 1, Size: 9, Paths: []string{"a/logo.png", "b/logo-old.png"}},
+			{ID: 2, Size: 5, Paths: []string{"b/copy.txt", "c/hello.txt", "hello.txt"}},
+		}
+		if !reflect.DeepEqual(groups, expected) {
+			t.Errorf("groups not match with %d workers\nGot:\n%v\nExpected:\n%v", workers, groups, expected)
+		}
+		if wasted := groups[1].Wasted(); wasted != 10 {
+			t.Errorf("wasted not match\nGot:\n%v\nExpected:\n%v", wasted, 10)
+		}
+
+		out := new(bytes.Buffer)
+		if err := Format(out, tree, opts); err != nil {
+			t.Fatal(err)
+		}
+		expectedTree := `├───a
+│	└───logo.png (9b) [dup 1]
+├───b
+│	├───copy.txt (5b) [dup 2]
+│	└───logo-old.png (9b) [dup 1]
+├───c
+│	├───hello.txt (5b) [dup 2]
+│	├───other.txt (5b)
+│	└───same-size (9b)
+├───empty1 (empty)
+├───empty2 (empty)
+├───hello.txt (5b) [dup 2]
+└───link.txt -> hello.txt (5b)
+`
+		if out.String() != expectedTree {
+			t.Errorf("tree not match with %d workers\nGot:\n%v\nExpected:\n%v", workers, out, expectedTree)
+		}
+	}
+}
+
+func TestFindDupesUnreadable(t *testing.T) {
+	fsys := failingFS{
+		FS: fstest.MapFS{
+			"a.txt": {Data: []byte("same")},
+			"b.txt": {Data: []byte("same")},
+			"c.txt": {Data: []byte("same")},
+		},
+		fail: "b.txt",
+	}
+	opts := Options{PrintFiles: true}
+	tree, err := Read(fsys, ".", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// нечитаемый файл пропускается, остальные группы остаются
+	groups := FindDupes(fsys, tree, 2)
+	expected := []DupeGroup{{ID: 1, Size: 4, Paths: []string{"a.txt", "c.txt"}}}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("groups not match\nGot:\n%v\nExpected:\n%v", groups, expected)
+	}
+
+	out := new(bytes.Buffer)
+	if err := Format(out, tree, opts); err != nil {
+		t.Fatal(err)
+	}
+	expectedTree := `├───a.txt (4b) [dup 1]
+├───b.txt [error reading file]
+└───c.txt (4b) [dup 1]
+`
+	if out.String() != expectedTree {
+		t.Errorf("tree not match\nGot:\n%v\nExpected:\n%v", out, expectedTree)
+	}
+	if err := tree.Children[1].Err; !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("wrong error\nGot:\n%v\nExpected:\n%v", err, fs.ErrPermission)
+	}
+}
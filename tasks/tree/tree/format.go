@@ -0,0 +1,342 @@
+package tree
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formatter выводит прочитанное дерево, root - корневой каталог обхода
+type Formatter interface {
+	Format(out io.Writer, root *Node, opts Options) error
+}
+
+// Formatters - форматы вывода по именам для Options.Format,
+// сюда можно добавить свой
+var Formatters = map[string]Formatter{
+	"text": TextFormatter{},
+	"json": JSONFormatter{},
+	"xml":  XMLFormatter{},
+	"yaml": YAMLFormatter{},
+	"html": HTMLFormatter{},
+}
+
+// diffMarks - пометки элементов в текстовом выводе Diff
+var diffMarks = map[DiffStatus]string{
+	Added:    "[+] ",
+	Removed:  "[-] ",
+	Modified: "[~] ",
+}
+
+// sizeChanged - изменился ли размер элемента в результате Diff
+func sizeChanged(n *Node, opts Options) bool {
+	return hasSize(n, opts) && n.Status == Modified && n.OldSize != n.Size
+}
+
+// diffStatus возвращает статус для структурных форматов, пустой вне Diff
+func diffStatus(n *Node) string {
+	if n.Status == Unchanged {
+		return ""
+	}
+	return n.Status.String()
+}
+
+func nodeType(n *Node) string {
+	if n.IsDir {
+		return "directory"
+	}
+	return "file"
+}
+
+// hasSize - выводится ли размер узла: у каталогов только с DU
+// и только если каталог был прочитан, у файлов - если их удалось прочитать
+func hasSize(n *Node, opts Options) bool {
+	if n.Broken || !n.IsDir && n.Err != nil {
+		return false
+	}
+	if n.IsDir && n.LinkTarget != "" && (n.Recursive || !opts.FollowLinks) {
+		return false
+	}
+	return !n.IsDir || opts.DU
+}
+
+var sizeUnits = []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+// formatSize возвращает размер для вывода в скобках после имени,
+// с human в двоичных единицах
+func formatSize(size int64, human bool) string {
+	if size == 0 {
+		return "empty"
+	}
+	if !human || size < 1024 {
+		return strconv.FormatInt(size, 10) + "b"
+	}
+	value := float64(size) / 1024
+	unit := 0
+	for value >= 1024 && unit < len(sizeUnits)-1 {
+		value /= 1024
+		unit++
+	}
+	return strconv.FormatFloat(value, 'f', 1, 64) + sizeUnits[unit]
+}
+
+// Plural - число n со словом one или many: "1 file", "2 files"
+func Plural(n int, one, many string) string {
+	if n == 1 {
+		return fmt.Sprint(n, " ", one)
+	}
+	return fmt.Sprint(n, " ", many)
+}
+
+// TextFormatter - исходный формат с символами псевдографики из Options.Style,
+// корень не выводится
+type TextFormatter struct{}
+
+func (TextFormatter) Format(out io.Writer, root *Node, opts Options) error {
+	w := bufio.NewWriter(out)
+	style := DefaultStyle
+	if opts.Style != nil {
+		style = *opts.Style
+	}
+	renderText(w, root.Children, "", style, opts)
+	return w.Flush()
+}
+
+func renderText(out io.Writer, nodes []*Node, prefix string, style Style, opts Options) {
+	for i, n := range nodes {
+		connector, indent := style.Branch, style.Vertical
+		if i == len(nodes)-1 {
+			connector, indent = style.Last, style.Space
+		}
+
+		fmt.Fprint(out, prefix, connector, diffMarks[n.Status])
+
+		if n.Meta != nil {
+			values := []string{}
+			for _, column := range metaColumns(n.Meta, opts) {
+				values = append(values, column.value)
+			}
+			fmt.Fprint(out, "[", strings.Join(values, " "), "] ")
+		}
+
+		if opts.Colors != nil {
+			fmt.Fprint(out, opts.Colors.paint(n))
+		} else {
+			fmt.Fprint(out, n.Name)
+		}
+
+		if n.LinkTarget != "" {
+			fmt.Fprint(out, " -> ", n.LinkTarget)
+		}
+
+		if sizeChanged(n, opts) {
+			fmt.Fprint(out, " (", formatSize(n.OldSize, opts.Human), " -> ", formatSize(n.Size, opts.Human), ")")
+		} else if hasSize(n, opts) {
+			fmt.Fprint(out, " (", formatSize(n.Size, opts.Human), ")")
+		}
+
+		if n.Dupe != 0 {
+			fmt.Fprint(out, " [dup ", n.Dupe, "]")
+		}
+
+		if n.Matches != 0 {
+			fmt.Fprint(out, " [", Plural(n.Matches, "match", "matches"), "]")
+		}
+
+		if n.Recursive {
+			fmt.Fprint(out, " [recursive, not followed]")
+		}
+
+		if n.Err != nil && n.IsDir {
+			fmt.Fprint(out, " [error opening dir]")
+		} else if n.Err != nil {
+			fmt.Fprint(out, " [error reading file]")
+		}
+
+		fmt.Fprint(out, "\n")
+
+		renderText(out, n.Children, prefix+indent, style, opts)
+	}
+}
+
+// JSONFormatter выводит дерево вложенными объектами name, type, size, children
+type JSONFormatter struct{}
+
+type jsonNode struct {
+	Name      string            `json:"name"`
+	Type      string            `json:"type"`
+	Size      *int64            `json:"size,omitempty"`
+	OldSize   *int64            `json:"old_size,omitempty"`
+	Target    string            `json:"target,omitempty"`
+	Recursive bool              `json:"recursive,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	Status    string            `json:"status,omitempty"`
+	Dupe      int               `json:"dupe,omitempty"`
+	Matches   int               `json:"matches,omitempty"`
+	Meta      map[string]string `json:"meta,omitempty"`
+	Children  []jsonNode        `json:"children,omitempty"`
+}
+
+func newJSONNode(n *Node, opts Options) jsonNode {
+	result := jsonNode{
+		Name:      n.Name,
+		Type:      nodeType(n),
+		Target:    n.LinkTarget,
+		Recursive: n.Recursive,
+		Status:    diffStatus(n),
+		Dupe:      n.Dupe,
+		Matches:   n.Matches,
+	}
+	if hasSize(n, opts) {
+		size := n.Size
+		result.Size = &size
+	}
+	if sizeChanged(n, opts) {
+		oldSize := n.OldSize
+		result.OldSize = &oldSize
+	}
+	if n.Err != nil {
+		result.Error = n.Err.Error()
+	}
+	if n.Meta != nil {
+		result.Meta = map[string]string{}
+		for _, column := range metaColumns(n.Meta, opts) {
+			result.Meta[column.key] = column.value
+		}
+	}
+	for _, child := range n.Children {
+		result.Children = append(result.Children, newJSONNode(child, opts))
+	}
+	return result
+}
+
+func (JSONFormatter) Format(out io.Writer, root *Node, opts Options) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(newJSONNode(root, opts))
+}
+
+// XMLFormatter выводит дерево в стиле tree -X
+type XMLFormatter struct{}
+
+type xmlNode struct {
+	XMLName   xml.Name
+	Name      string     `xml:"name,attr"`
+	Size      *int64     `xml:"size,attr,omitempty"`
+	OldSize   *int64     `xml:"old_size,attr,omitempty"`
+	Target    string     `xml:"target,attr,omitempty"`
+	Recursive bool       `xml:"recursive,attr,omitempty"`
+	Error     string     `xml:"error,attr,omitempty"`
+	Status    string     `xml:"status,attr,omitempty"`
+	Dupe      int        `xml:"dupe,attr,omitempty"`
+	Matches   int        `xml:"matches,attr,omitempty"`
+	Meta      []xml.Attr `xml:",any,attr"`
+	Children  []xmlNode
+}
+
+func newXMLNode(n *Node, opts Options) xmlNode {
+	result := xmlNode{
+		XMLName:   xml.Name{Local: nodeType(n)},
+		Name:      n.Name,
+		Target:    n.LinkTarget,
+		Recursive: n.Recursive,
+		Status:    diffStatus(n),
+		Dupe:      n.Dupe,
+		Matches:   n.Matches,
+	}
+	if hasSize(n, opts) {
+		size := n.Size
+		result.Size = &size
+	}
+	if sizeChanged(n, opts) {
+		oldSize := n.OldSize
+		result.OldSize = &oldSize
+	}
+	if n.Err != nil {
+		result.Error = n.Err.Error()
+	}
+	if n.Meta != nil {
+		for _, column := range metaColumns(n.Meta, opts) {
+			result.Meta = append(result.Meta, xml.Attr{Name: xml.Name{Local: column.key}, Value: column.value})
+		}
+	}
+	for _, child := range n.Children {
+		result.Children = append(result.Children, newXMLNode(child, opts))
+	}
+	return result
+}
+
+func (XMLFormatter) Format(out io.Writer, root *Node, opts Options) error {
+	tree := struct {
+		XMLName xml.Name `xml:"tree"`
+		Root    xmlNode
+	}{Root: newXMLNode(root, opts)}
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(tree); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
+}
+
+// YAMLFormatter выводит то же, что JSONFormatter, в виде YAML
+type YAMLFormatter struct{}
+
+func (YAMLFormatter) Format(out io.Writer, root *Node, opts Options) error {
+	w := bufio.NewWriter(out)
+	renderYAML(w, root, "", "", opts)
+	return w.Flush()
+}
+
+// renderYAML выводит узел, first - отступ первой строки, indent - остальных.
+// Имена всегда в двойных кавычках: экранирование strconv.Quote совместимо с YAML.
+func renderYAML(out io.Writer, n *Node, first, indent string, opts Options) {
+	fmt.Fprint(out, first, "name: ", strconv.Quote(n.Name), "\n")
+	fmt.Fprint(out, indent, "type: ", nodeType(n), "\n")
+	if hasSize(n, opts) {
+		fmt.Fprint(out, indent, "size: ", n.Size, "\n")
+	}
+	if sizeChanged(n, opts) {
+		fmt.Fprint(out, indent, "old_size: ", n.OldSize, "\n")
+	}
+	if n.LinkTarget != "" {
+		fmt.Fprint(out, indent, "target: ", strconv.Quote(n.LinkTarget), "\n")
+	}
+	if n.Recursive {
+		fmt.Fprint(out, indent, "recursive: true\n")
+	}
+	if n.Err != nil {
+		fmt.Fprint(out, indent, "error: ", strconv.Quote(n.Err.Error()), "\n")
+	}
+	if n.Status != Unchanged {
+		fmt.Fprint(out, indent, "status: ", n.Status, "\n")
+	}
+	if n.Dupe != 0 {
+		fmt.Fprint(out, indent, "dupe: ", n.Dupe, "\n")
+	}
+	if n.Matches != 0 {
+		fmt.Fprint(out, indent, "matches: ", n.Matches, "\n")
+	}
+	if n.Meta != nil {
+		fmt.Fprint(out, indent, "meta:\n")
+		for _, column := range metaColumns(n.Meta, opts) {
+			fmt.Fprint(out, indent, "  ", column.key, ": ", strconv.Quote(column.value), "\n")
+		}
+	}
+	if len(n.Children) == 0 {
+		return
+	}
+	fmt.Fprint(out, indent, "children:\n")
+	for _, child := range n.Children {
+		renderYAML(out, child, indent+"  - ", indent+"    ", opts)
+	}
+}
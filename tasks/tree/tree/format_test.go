@@ -0,0 +1,122 @@
+package tree
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+const testXMLResult = `<?xml version="1.0" encoding="UTF-8"?>
+<tree>
+  <directory name="zline">
+    <file name="empty.txt" size="0"></file>
+    <directory name="lorem">
+      <file name="dolor.txt" size="0"></file>
+      <file name="gopher.png" size="70372"></file>
+      <directory name="ipsum">
+        <file name="gopher.png" size="70372"></file>
+      </directory>
+    </directory>
+  </directory>
+</tree>
+`
+
+const testYAMLResult = `name: "zline"
+type: directory
+children:
+  - name: "empty.txt"
+    type: file
+    size: 0
+  - name: "lorem"
+    type: directory
+    children:
+      - name: "dolor.txt"
+        type: file
+        size: 0
+      - name: "gopher.png"
+        type: file
+        size: 70372
+      - name: "ipsum"
+        type: directory
+        children:
+          - name: "gopher.png"
+            type: file
+            size: 70372
+`
+
+// readAndFormat выводит поддерево testFS с корнем root
+func readAndFormat(out io.Writer, root string, opts Options) error {
+	tree, err := Read(testFS, root, opts)
+	if err != nil {
+		return err
+	}
+	return Format(out, tree, opts)
+}
+
+func TestFormatXML(t *testing.T) {
+	out := new(bytes.Buffer)
+	err := readAndFormat(out, "zline", Options{PrintFiles: true, Format: "xml"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result := out.String(); result != testXMLResult {
+		t.Errorf("results not match\nGot:\n%v\nExpected:\n%v", result, testXMLResult)
+	}
+}
+
+func TestFormatYAML(t *testing.T) {
+	out := new(bytes.Buffer)
+	err := readAndFormat(out, "zline", Options{PrintFiles: true, Format: "yaml"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result := out.String(); result != testYAMLResult {
+		t.Errorf("results not match\nGot:\n%v\nExpected:\n%v", result, testYAMLResult)
+	}
+}
+
+func TestFormatJSON(t *testing.T) {
+	out := new(bytes.Buffer)
+	err := readAndFormat(out, "zline", Options{PrintFiles: true, Format: "json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	root := jsonNode{}
+	if err := json.Unmarshal(out.Bytes(), &root); err != nil {
+		t.Fatalf("cant unpack result: %v", err)
+	}
+
+	if root.Name != "zline" || root.Type != "directory" || root.Size != nil || len(root.Children) != 2 {
+		t.Fatalf("wrong root: %+v", root)
+	}
+	empty := root.Children[0]
+	if empty.Name != "empty.txt" || empty.Type != "file" || empty.Size == nil || *empty.Size != 0 {
+		t.Errorf("wrong file: %+v", empty)
+	}
+	ipsum := root.Children[1].Children[2]
+	if ipsum.Name != "ipsum" || len(ipsum.Children) != 1 || *ipsum.Children[0].Size != 70372 {
+		t.Errorf("wrong directory: %+v", ipsum)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	cases := []struct {
+		size     int64
+		human    bool
+		expected string
+	}{
+		{0, true, "empty"},
+		{70372, false, "70372b"},
+		{1023, true, "1023b"},
+		{70372, true, "68.7KiB"},
+		{5 << 20, true, "5.0MiB"},
+		{3 << 40, true, "3.0TiB"},
+	}
+	for _, c := range cases {
+		if result := formatSize(c.size, c.human); result != c.expected {
+			t.Errorf("formatSize(%d, %v)\nGot: %v\nExpected: %v", c.size, c.human, result, c.expected)
+		}
+	}
+}
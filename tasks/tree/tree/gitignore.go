@@ -0,0 +1,138 @@
+package tree
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// ignoreRule - одно правило из файла .gitignore
+type ignoreRule struct {
+	base     string   // каталог .gitignore относительно корня, "" для корня
+	segments []string // шаблон, разбитый по "/"
+	negate   bool
+	dirOnly  bool
+	anchored bool // шаблон содержит "/" и сопоставляется с путём от base
+}
+
+// loadGitignore дописывает к rules правила из файла .gitignore каталога dir,
+// rel - путь этого каталога относительно корня обхода
+func loadGitignore(fsys fs.FS, dir, rel string, rules []ignoreRule) ([]ignoreRule, error) {
+
+	file, err := fsys.Open(path.Join(dir, ".gitignore"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return rules, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// не портим срез родительского каталога
+	rules = rules[:len(rules):len(rules)]
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text(), rel); ok {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, scanner.Err()
+}
+
+func parseIgnoreRule(line, base string) (ignoreRule, bool) {
+
+	rule := ignoreRule{base: base}
+
+	line = strings.TrimRight(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule, false
+	}
+
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return rule, false
+	}
+
+	rule.segments = strings.Split(line, "/")
+
+	return rule, true
+}
+
+func (r ignoreRule) match(rel string, isDir bool) bool {
+
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		rel = rel[len(r.base)+1:]
+	}
+
+	if !r.anchored {
+		ok, _ := path.Match(r.segments[0], path.Base(rel))
+		return ok
+	}
+
+	return matchSegments(r.segments, strings.Split(rel, "/"))
+}
+
+// matchSegments сопоставляет путь с шаблоном по сегментам,
+// "**" соответствует любому числу сегментов
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			// "dir/**" - всё внутри dir, но не сам dir
+			if len(rest) == 0 {
+				return len(name) > 0
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// ignored применяет правила по порядку, решает последнее совпавшее,
+// поэтому правила вложенных .gitignore перекрывают родительские
+func ignored(rules []ignoreRule, rel string, isDir bool) bool {
+	result := false
+	for _, rule := range rules {
+		if rule.match(rel, isDir) {
+			result = !rule.negate
+		}
+	}
+	return result
+}
@@ -0,0 +1,80 @@
+package tree
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+)
+
+func TestIgnoreRules(t *testing.T) {
+	cases := []struct {
+		lines   []string
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{[]string{"*.log"}, "a/b/debug.log", false, true},
+		{[]string{"*.log", "!keep.log"}, "a/keep.log", false, false},
+		{[]string{"build/"}, "build", false, false},
+		{[]string{"build/"}, "src/build", true, true},
+		{[]string{"/build"}, "src/build", true, false},
+		{[]string{"/build"}, "build", true, true},
+		{[]string{"doc/*.txt"}, "doc/notes.txt", false, true},
+		{[]string{"doc/*.txt"}, "doc/server/arch.txt", false, false},
+		{[]string{"**/logs"}, "a/b/logs", true, true},
+		{[]string{"a/**/b"}, "a/b", true, true},
+		{[]string{"a/**/b"}, "a/x/y/b", true, true},
+		{[]string{"a/**"}, "a", true, false},
+		{[]string{"a/**"}, "a/x", false, true},
+		{[]string{"# comment", "", "\\#hash"}, "#hash", false, true},
+		{[]string{"trailing   "}, "trailing", false, true},
+	}
+
+	for _, c := range cases {
+		rules := []ignoreRule{}
+		for _, line := range c.lines {
+			if rule, ok := parseIgnoreRule(line, ""); ok {
+				rules = append(rules, rule)
+			}
+		}
+		if result := ignored(rules, c.path, c.isDir); result != c.ignored {
+			t.Errorf("rules %q, path %q\nGot: %v\nExpected: %v", c.lines, c.path, result, c.ignored)
+		}
+	}
+}
+
+const testGitignoreResult = `├───.gitignore (21b)
+├───keep.log (empty)
+├───src
+│	├───.gitignore (18b)
+│	├───main.go (empty)
+│	└───trace.log (empty)
+└───vendor
+	└───lib.go (empty)
+`
+
+func TestTreeGitignore(t *testing.T) {
+	fsys := fstest.MapFS{
+		".git/HEAD":          {},
+		".gitignore":         {Data: []byte("*.log\n!keep.log\nbin/\n")},
+		"bin/app":            {},
+		"debug.log":          {},
+		"keep.log":           {},
+		"src/.gitignore":     {Data: []byte("!trace.log\n/gen/*\n")},
+		"src/gen/api.go":     {},
+		"src/main.go":        {},
+		"src/trace.log":      {},
+		"src/tmp/bin/cache":  {},
+		"vendor/lib.go":      {},
+		"vendor/bin/tool.go": {},
+	}
+
+	out := new(bytes.Buffer)
+	err := Render(out, fsys, Options{PrintFiles: true, Gitignore: true, Prune: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result := out.String(); result != testGitignoreResult {
+		t.Errorf("results not match\nGot:\n%v\nExpected:\n%v", result, testGitignoreResult)
+	}
+}
@@ -0,0 +1,129 @@
+package tree
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"sync"
+)
+
+// binaryCheckSize - сколько байт в начале файла проверяется на нулевой
+// байт, как это делает git
+const binaryCheckSize = 8000
+
+// maxLineSize - строки длиннее не ищутся, файл с ними пропускается
+const maxLineSize = 1 << 20
+
+// grepResult - число совпавших строк в файле, 0 - файл не подходит
+type grepResult struct {
+	path    string
+	node    *Node
+	matches int
+	err     error
+}
+
+// Grep возвращает копию дерева, прочитанного из fsys с PrintFiles, в которой
+// остались только файлы со строками, подходящими под re, и ведущие к ним
+// каталоги. Число таких строк записывается в Node.Matches. Файлы читаются
+// построчно, не больше opts.Workers одновременно. Пропускаются двоичные файлы,
+// ссылки на каталоги и файлы больше opts.GrepMaxSize, если он задан.
+// Нечитаемый файл не прерывает поиск: он остаётся в дереве с ошибкой в Node.Err.
+func Grep(fsys fs.FS, root *Node, re *regexp.Regexp, opts Options) *Node {
+
+	files := []*grepResult{}
+	var collect func(nodes []*Node, dir string)
+	collect = func(nodes []*Node, dir string) {
+		for _, n := range nodes {
+			name := path.Join(dir, n.Name)
+			switch {
+			case n.IsDir:
+				collect(n.Children, name)
+			case n.Broken, opts.GrepMaxSize > 0 && n.Size > opts.GrepMaxSize:
+			default:
+				files = append(files, &grepResult{path: name, node: n})
+			}
+		}
+	}
+	collect(root.Children, "")
+
+	p := newPool(opts.Workers)
+	wg := &sync.WaitGroup{}
+	for _, f := range files {
+		p.run(wg, func() {
+			f.matches, f.err = grepFile(fsys, f.path, re)
+		})
+	}
+	wg.Wait()
+
+	found := map[*Node]*grepResult{}
+	for _, f := range files {
+		if f.matches > 0 || f.err != nil {
+			found[f.node] = f
+		}
+	}
+
+	result := copyNode(root)
+	result.Children = pruneMatches(root.Children, found)
+	return result
+}
+
+// pruneMatches копирует элементы, среди потомков которых есть совпадения
+// или файлы, которые не удалось прочитать
+func pruneMatches(nodes []*Node, found map[*Node]*grepResult) []*Node {
+	result := []*Node{}
+	for _, n := range nodes {
+		if n.IsDir {
+			children := pruneMatches(n.Children, found)
+			if len(children) == 0 {
+				continue
+			}
+			dir := copyNode(n)
+			dir.Children = children
+			result = append(result, dir)
+			continue
+		}
+		if f := found[n]; f != nil {
+			file := copyNode(n)
+			file.Matches = f.matches
+			file.Err = f.err
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+// grepFile считает строки файла, подходящие под re. Двоичные файлы
+// и файлы со слишком длинными строками дают 0.
+func grepFile(fsys fs.FS, name string, re *regexp.Regexp) (int, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	r := bufio.NewReaderSize(file, binaryCheckSize)
+	head, err := r.Peek(binaryCheckSize)
+	if err != nil && err != io.EOF && !errors.Is(err, bufio.ErrBufferFull) {
+		return 0, err
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return 0, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineSize)
+	count := 0
+	for scanner.Scan() {
+		if re.Match(scanner.Bytes()) {
+			count++
+		}
+	}
+	if errors.Is(scanner.Err(), bufio.ErrTooLong) {
+		return 0, nil
+	}
+	return count, scanner.Err()
+}
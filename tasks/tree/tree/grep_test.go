@@ -0,0 +1,92 @@
+package tree
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGrep(t *testing.T) {
+	fsys := fstest.MapFS{
+		"cmd/main.go":          {Data: []byte("package main\n\n// TODO: flags\nfunc main() {}\n")},
+		"cmd/tool/tool.go":     {Data: []byte("package tool\n")},
+		"docs/README.md":       {Data: []byte("TODO: write\nTODO: review\n")},
+		"docs/img/logo.png":    {Data: []byte("PNG\x00TODO")},
+		"internal/a/b/deep.go": {Data: []byte("// TODO\n")},
+		"internal/c/skip.go":   {Data: []byte("package c\n")},
+		"big.log":              {Data: []byte(strings.Repeat("TODO\n", 100))},
+		"long.txt":             {Data: []byte(strings.Repeat("x", maxLineSize+1) + "\nTODO\n")},
+		"link.md":              {Data: []byte("docs/README.md"), Mode: fs.ModeSymlink},
+		"zz.txt":               {Data: []byte("nothing")},
+	}
+
+	opts := Options{PrintFiles: true, GrepMaxSize: 100, Workers: 4}
+	root, err := Read(fsys, ".", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matched := Grep(fsys, root, regexp.MustCompile(`TODO`), opts)
+
+	out := new(bytes.Buffer)
+	if err := Format(out, matched, opts); err != nil {
+		t.Fatal(err)
+	}
+	expected := `├───cmd
+│	└───main.go (44b) [1 match]
+├───docs
+│	└───README.md (25b) [2 matches]
+├───internal
+│	└───a
+│		└───b
+│			└───deep.go (8b) [1 match]
+└───link.md -> docs/README.md (25b) [2 matches]
+`
+	if out.String() != expected {
+		t.Errorf("grep not match\nGot:\n%v\nExpected:\n%v", out, expected)
+	}
+
+	if len(root.Children) != 7 || root.Children[0].Matches != 0 {
+		t.Errorf("source tree changed by grep\nGot:\n%v", root.Children)
+	}
+}
+
+func TestGrepUnreadable(t *testing.T) {
+	fsys := failingFS{
+		FS: fstest.MapFS{
+			"a.txt":     {Data: []byte("TODO")},
+			"dir/b.txt": {Data: []byte("TODO")},
+			"c.txt":     {Data: []byte("done")},
+		},
+		fail: "dir/b.txt",
+	}
+	opts := Options{PrintFiles: true}
+	root, err := Read(fsys, ".", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// нечитаемый файл не прерывает поиск и остаётся в дереве с ошибкой
+	matched := Grep(fsys, root, regexp.MustCompile(`TODO`), opts)
+
+	out := new(bytes.Buffer)
+	if err := Format(out, matched, opts); err != nil {
+		t.Fatal(err)
+	}
+	expected := `├───a.txt (4b) [1 match]
+└───dir
+	└───b.txt [error reading file]
+`
+	if out.String() != expected {
+		t.Errorf("grep not match\nGot:\n%v\nExpected:\n%v", out, expected)
+	}
+	if err := matched.Children[1].Children[0].Err; !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("wrong error\nGot:\n%v\nExpected:\n%v", err, fs.ErrPermission)
+	}
+	if root.Children[2].Children[0].Err != nil {
+		t.Errorf("source tree changed by grep\nGot:\n%v", root.Children[2].Children[0].Err)
+	}
+}
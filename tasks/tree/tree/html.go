@@ -0,0 +1,83 @@
+package tree
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+	"net/url"
+	"strings"
+)
+
+//go:embed tree.html.tmpl
+var defaultHTML string
+
+// DefaultHTMLTemplate - шаблон HTMLFormatter, если не задан Options.HTMLTemplate
+var DefaultHTMLTemplate = template.Must(template.New("tree").Parse(defaultHTML))
+
+// HTMLPage - данные для шаблона HTMLFormatter
+type HTMLPage struct {
+	Title   string
+	BaseURL string
+	Root    HTMLNode
+}
+
+// HTMLNode - элемент дерева для шаблона, Size пустой, если размер не выводится
+type HTMLNode struct {
+	Name     string
+	Href     string
+	IsDir    bool
+	Size     string
+	Target   string
+	Error    string
+	Status   string
+	Children []HTMLNode
+}
+
+// HTMLFormatter выводит самостоятельную страницу в стиле tree -H:
+// вложенные списки, каталоги сворачиваются, ссылки строятся от Options.BaseURL.
+// Все значения экранирует html/template.
+type HTMLFormatter struct{}
+
+func (HTMLFormatter) Format(out io.Writer, root *Node, opts Options) error {
+	tmpl := opts.HTMLTemplate
+	if tmpl == nil {
+		tmpl = DefaultHTMLTemplate
+	}
+	page := HTMLPage{
+		Title:   root.Name,
+		BaseURL: opts.BaseURL,
+		Root:    newHTMLNode(root, strings.TrimSuffix(opts.BaseURL, "/"), opts),
+	}
+	return tmpl.Execute(out, page)
+}
+
+// newHTMLNode строит узел шаблона, href - ссылка на сам узел
+func newHTMLNode(n *Node, href string, opts Options) HTMLNode {
+	result := HTMLNode{
+		Name:   n.Name,
+		Href:   href,
+		IsDir:  n.IsDir,
+		Target: n.LinkTarget,
+		Status: diffStatus(n),
+	}
+	if href == "" {
+		result.Href = "."
+	}
+	if n.IsDir {
+		result.Href += "/"
+	}
+	if hasSize(n, opts) {
+		result.Size = formatSize(n.Size, opts.Human)
+	}
+	if n.Err != nil {
+		result.Error = n.Err.Error()
+	}
+	for _, child := range n.Children {
+		childHref := url.PathEscape(child.Name)
+		if href != "" {
+			childHref = href + "/" + childHref
+		}
+		result.Children = append(result.Children, newHTMLNode(child, childHref, opts))
+	}
+	return result
+}
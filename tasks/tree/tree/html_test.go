@@ -0,0 +1,61 @@
+package tree
+
+import (
+	"bytes"
+	"html/template"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestHTMLFormatter(t *testing.T) {
+	fsys := fstest.MapFS{
+		"docs":             {Mode: fs.ModeDir},
+		"docs/<script>.md": {Data: []byte("xss")},
+		"docs/a & b.txt":   {Data: []byte("ab")},
+		"empty":            {Mode: fs.ModeDir},
+	}
+	opts := Options{PrintFiles: true, Format: "html", BaseURL: "https://example.com/repo/"}
+
+	out := new(bytes.Buffer)
+	if err := Render(out, fsys, opts); err != nil {
+		t.Fatalf("test for OK Failed - error: %v", err)
+	}
+
+	expected := []string{
+		`<title>.</title>`,
+		`<li class="dir"><details open><summary><a href="https://example.com/repo/docs/">docs</a></summary>`,
+		`<li class="file"><a href="https://example.com/repo/docs/%3Cscript%3E.md">&lt;script&gt;.md</a> <span class="size">(3b)</span></li>`,
+		`<li class="file"><a href="https://example.com/repo/docs/a%20&amp;%20b.txt">a &amp; b.txt</a> <span class="size">(2b)</span></li>`,
+		`<li class="dir"><a href="https://example.com/repo/empty/">empty</a></li>`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("html not contains line\nGot:\n%v\nExpected:\n%v", out, line)
+		}
+	}
+	if strings.Contains(out.String(), "<script>") {
+		t.Errorf("html contains unescaped name\nGot:\n%v", out)
+	}
+}
+
+func TestHTMLCustomTemplate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt": {Data: []byte("a")},
+		"b":     {Mode: fs.ModeDir},
+	}
+	tmpl := template.Must(template.New("list").Parse(
+		`{{range .Root.Children}}{{.Href}} {{.Name}}{{with .Size}} {{.}}{{end}};{{end}}`))
+	opts := Options{PrintFiles: true, Format: "html", HTMLTemplate: tmpl}
+
+	out := new(bytes.Buffer)
+	if err := Render(out, fsys, opts); err != nil {
+		t.Fatalf("test for OK Failed - error: %v", err)
+	}
+
+	expected := "a.txt a.txt 1b;b/ b;"
+	if out.String() != expected {
+		t.Errorf("test for OK Failed - results not match\nGot:\n%v\nExpected:\n%v", out, expected)
+	}
+}
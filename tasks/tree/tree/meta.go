@@ -0,0 +1,169 @@
+package tree
+
+import (
+	"io/fs"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeFormat - формат времени изменения, если не задан Options.TimeFormat
+const DefaultTimeFormat = "Jan _2 15:04"
+
+// Meta - метаданные элемента для колонок прав, владельца, группы, времени
+// и inode. Для ссылок берутся данные самой ссылки, а не цели.
+// UID, GID и Inode заполняются, только если fs.FS их отдаёт.
+type Meta struct {
+	Mode    fs.FileMode
+	UID     string
+	GID     string
+	Inode   uint64
+	ModTime time.Time
+}
+
+func newMeta(info fs.FileInfo) *Meta {
+	m := &Meta{Mode: info.Mode(), ModTime: info.ModTime()}
+	if id, ok := getFileID(info); ok {
+		m.Inode = id.ino
+	}
+	m.UID, m.GID, _ = getOwner(info)
+	return m
+}
+
+// showMeta - включена ли хотя бы одна колонка метаданных
+func (opts Options) showMeta() bool {
+	return opts.ShowMode || opts.ShowUser || opts.ShowGroup || opts.ShowTime || opts.ShowInode
+}
+
+type metaColumn struct {
+	key   string
+	value string
+}
+
+// metaColumns возвращает включённые колонки в порядке inode, права,
+// владелец, группа, время изменения
+func metaColumns(m *Meta, opts Options) []metaColumn {
+	columns := []metaColumn{}
+	if opts.ShowInode {
+		columns = append(columns, metaColumn{"inode", strconv.FormatUint(m.Inode, 10)})
+	}
+	if opts.ShowMode {
+		columns = append(columns, metaColumn{"mode", modeString(m.Mode)})
+	}
+	if opts.ShowUser {
+		columns = append(columns, metaColumn{"user", lookupName(userNames, m.UID, lookupUser)})
+	}
+	if opts.ShowGroup {
+		columns = append(columns, metaColumn{"group", lookupName(groupNames, m.GID, lookupGroup)})
+	}
+	if opts.ShowTime {
+		columns = append(columns, metaColumn{"time", m.ModTime.Format(timeFormat(opts))})
+	}
+	return columns
+}
+
+func timeFormat(opts Options) string {
+	if opts.TimeFormat == "" {
+		return DefaultTimeFormat
+	}
+	return opts.TimeFormat
+}
+
+// modeString возвращает права в виде ls -l, например drwxr-xr-x
+func modeString(mode fs.FileMode) string {
+	buf := []byte("----------")
+
+	switch {
+	case mode.IsDir():
+		buf[0] = 'd'
+	case mode&fs.ModeSymlink != 0:
+		buf[0] = 'l'
+	case mode&fs.ModeNamedPipe != 0:
+		buf[0] = 'p'
+	case mode&fs.ModeSocket != 0:
+		buf[0] = 's'
+	case mode&fs.ModeCharDevice != 0:
+		buf[0] = 'c'
+	case mode&fs.ModeDevice != 0:
+		buf[0] = 'b'
+	}
+
+	const rwx = "rwxrwxrwx"
+	for i := 0; i < 9; i++ {
+		if mode&(1<<uint(8-i)) != 0 {
+			buf[i+1] = rwx[i]
+		}
+	}
+
+	special := []struct {
+		flag fs.FileMode
+		pos  int
+		set  byte
+	}{
+		{fs.ModeSetuid, 3, 's'},
+		{fs.ModeSetgid, 6, 's'},
+		{fs.ModeSticky, 9, 't'},
+	}
+	for _, s := range special {
+		if mode&s.flag == 0 {
+			continue
+		}
+		if buf[s.pos] == '-' {
+			buf[s.pos] = s.set - 'a' + 'A'
+		} else {
+			buf[s.pos] = s.set
+		}
+	}
+
+	return string(buf)
+}
+
+// nameCache - кеш имён пользователей или групп по id,
+// чтобы не обращаться к /etc/passwd на каждый файл
+type nameCache struct {
+	mu    sync.Mutex
+	names map[string]string
+}
+
+var (
+	userNames  = &nameCache{names: map[string]string{}}
+	groupNames = &nameCache{names: map[string]string{}}
+)
+
+func lookupUser(id string) (string, error) {
+	u, err := user.LookupId(id)
+	if err != nil {
+		return "", err
+	}
+	return u.Username, nil
+}
+
+func lookupGroup(id string) (string, error) {
+	g, err := user.LookupGroupId(id)
+	if err != nil {
+		return "", err
+	}
+	return g.Name, nil
+}
+
+// lookupName возвращает имя по id, а если его нет - сам id
+func lookupName(cache *nameCache, id string, lookup func(string) (string, error)) string {
+	if id == "" {
+		return "?"
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if name, ok := cache.names[id]; ok {
+		return name
+	}
+	name, err := lookup(id)
+	if err != nil || strings.TrimSpace(name) == "" {
+		name = id
+	}
+	cache.names[id] = name
+	return name
+}
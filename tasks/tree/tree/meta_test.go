@@ -0,0 +1,109 @@
+package tree
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestModeString(t *testing.T) {
+	cases := []struct {
+		mode     fs.FileMode
+		expected string
+	}{
+		{0644, "-rw-r--r--"},
+		{fs.ModeDir | 0755, "drwxr-xr-x"},
+		{fs.ModeSymlink | 0777, "lrwxrwxrwx"},
+		{fs.ModeSetuid | 0755, "-rwsr-xr-x"},
+		{fs.ModeSetgid | 0640, "-rw-r-S---"},
+		{fs.ModeDir | fs.ModeSticky | 0777, "drwxrwxrwt"},
+		{fs.ModeNamedPipe | 0600, "prw-------"},
+	}
+	for _, c := range cases {
+		if result := modeString(c.mode); result != c.expected {
+			t.Errorf("modeString(%v)\nGot: %v\nExpected: %v", c.mode, result, c.expected)
+		}
+	}
+}
+
+func TestTreeMeta(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix permissions are not supported")
+	}
+
+	root := t.TempDir()
+	fixture := fstest.MapFS{
+		"dir/file.txt": {Data: []byte("content")},
+		"empty.txt":    {},
+	}
+	if err := os.CopyFS(root, fixture); err != nil {
+		t.Fatal(err)
+	}
+
+	mtime := time.Date(2021, time.March, 4, 5, 6, 0, 0, time.Local)
+	for name, mode := range map[string]fs.FileMode{"dir": 0750, "dir/file.txt": 0640, "empty.txt": 0600} {
+		path := filepath.Join(root, name)
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	uid := strconv.Itoa(os.Getuid())
+	gid := strconv.Itoa(os.Getgid())
+	owner := lookupName(userNames, uid, lookupUser)
+	group := lookupName(groupNames, gid, lookupGroup)
+	inode := func(name string) string {
+		info, err := os.Stat(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, _ := getFileID(info)
+		return strconv.FormatUint(id.ino, 10)
+	}
+
+	cases := []struct {
+		opts     Options
+		expected string
+	}{
+		{
+			opts: Options{PrintFiles: true, ShowMode: true},
+			expected: `├───[drwxr-x---] dir
+│	└───[-rw-r-----] file.txt (7b)
+└───[-rw-------] empty.txt (empty)
+`,
+		},
+		{
+			opts: Options{ShowMode: true, ShowUser: true, ShowGroup: true, ShowTime: true},
+			expected: `└───[drwxr-x--- ` + owner + ` ` + group + ` Mar  4 05:06] dir
+`,
+		},
+		{
+			opts: Options{PrintFiles: true, ShowInode: true, ShowTime: true, TimeFormat: "2006-01-02"},
+			expected: `├───[` + inode("dir") + ` 2021-03-04] dir
+│	└───[` + inode("dir/file.txt") + ` 2021-03-04] file.txt (7b)
+└───[` + inode("empty.txt") + ` 2021-03-04] empty.txt (empty)
+`,
+		},
+	}
+
+	for _, c := range cases {
+		out := new(bytes.Buffer)
+		err := Render(out, os.DirFS(root), c.opts)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if result := out.String(); result != c.expected {
+			t.Errorf("results not match for %+v\nGot:\n%v\nExpected:\n%v", c.opts, result, c.expected)
+		}
+	}
+}
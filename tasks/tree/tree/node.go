@@ -0,0 +1,268 @@
+package tree
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"sync"
+	"time"
+)
+
+// Node - элемент прочитанного дерева
+type Node struct {
+	Name       string
+	IsDir      bool        // для ссылок - тип цели
+	Size       int64       // для каталогов заполняется только с DU
+	Mode       fs.FileMode // для ссылок - режим цели, у каталогов может быть не заполнен
+	ModTime    time.Time
+	LinkTarget string     // для символических ссылок
+	Broken     bool       // цель ссылки не существует
+	Recursive  bool       // ссылка ведёт в каталог выше по дереву и не раскрыта
+	Meta       *Meta      // только если включены колонки метаданных
+	Err        error      // ошибка чтения каталога, а после Grep и FindDupes и файла
+	Status     DiffStatus // только в результате Diff
+	OldSize    int64      // размер в старом дереве, только в результате Diff
+	Dupe       int        // номер группы одинаковых файлов, только после FindDupes
+	Matches    int        // число совпавших строк, только в результате Grep
+	Children   []*Node
+}
+
+// ancestor - каталог на пути от корня до текущего
+type ancestor struct {
+	info fs.FileInfo
+	real string // путь с раскрытыми ссылками, если нет inode
+}
+
+// dirState - то, что каталог передаёт своим подкаталогам при обходе
+type dirState struct {
+	path      string // путь внутри fs.FS
+	real      string // путь с раскрытыми ссылками
+	level     int
+	rules     []ignoreRule // действующие правила .gitignore
+	ancestors []ancestor   // только с FollowLinks
+}
+
+// rel возвращает путь относительно корня обхода для правил .gitignore
+func (d dirState) rel() string {
+	if d.path == "." {
+		return ""
+	}
+	return d.path
+}
+
+// walker - общее для всего обхода состояние
+type walker struct {
+	fsys fs.FS
+	opts Options
+	pool *pool // nil - последовательный обход
+}
+
+// entryResult - результат обработки одного элемента каталога
+type entryResult struct {
+	node *Node // nil, если элемент не выводится
+	size int64 // вклад в размер каталога для DU
+}
+
+// readDir читает каталог и рекурсивно его подкаталоги.
+// Каждый каталог читается ровно один раз.
+// Возвращает видимые элементы и суммарный размер файлов в каталоге для DU.
+func (w *walker) readDir(d dirState) ([]*Node, int64, error) {
+
+	entries, err := fs.ReadDir(w.fsys, d.path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if w.opts.Gitignore {
+		d.rules, err = loadGitignore(w.fsys, d.path, d.rel(), d.rules)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	// элементы обрабатываются параллельно, если есть свободные воркеры,
+	// но результаты собираются по индексу, поэтому порядок не меняется
+	results := make([]entryResult, len(entries))
+	wg := &sync.WaitGroup{}
+	for i, entry := range entries {
+		w.pool.run(wg, func() {
+			results[i] = w.readEntry(d, entry)
+		})
+	}
+	wg.Wait()
+
+	nodes := make([]*Node, 0, len(entries))
+	var total int64
+
+	for _, result := range results {
+		total += result.size
+		if result.node != nil {
+			nodes = append(nodes, result.node)
+		}
+	}
+
+	sortNodes(nodes, w.opts)
+
+	return nodes, total, nil
+}
+
+// failed - результат для элемента, о котором не удалось узнать всё нужное:
+// он выводится с ошибкой в Node.Err, если такие элементы вообще выводятся
+func (w *walker) failed(n *Node) entryResult {
+	if !n.IsDir && !w.opts.PrintFiles {
+		return entryResult{}
+	}
+	return entryResult{node: n}
+}
+
+// readEntry обрабатывает элемент каталога d, для подкаталогов - рекурсивно
+func (w *walker) readEntry(d dirState, entry fs.DirEntry) entryResult {
+
+	opts := w.opts
+	n := &Node{Name: entry.Name(), IsDir: entry.IsDir()}
+	entryPath := path.Join(d.path, n.Name)
+
+	// ошибка одного элемента не прерывает обход, она выводится в дереве
+	var info fs.FileInfo
+	var err error
+	if entry.Type()&fs.ModeSymlink != 0 {
+		n.LinkTarget, err = fs.ReadLink(w.fsys, entryPath)
+		switch {
+		case err != nil:
+			// fs.FS без ReadLink, например zip.Reader, не умеет читать ссылки:
+			// ссылка выводится без цели, остальные ошибки - как ошибки узла
+			n.Broken = true
+			if !errors.Is(err, fs.ErrInvalid) {
+				n.Err = err
+			}
+		default:
+			info, err = fs.Stat(w.fsys, entryPath)
+			if err != nil {
+				n.Broken = true
+				info = nil
+			} else {
+				n.IsDir = info.IsDir()
+			}
+		}
+	}
+
+	// файлы без PrintFiles нужны только для подсчёта размера каталогов
+	if !opts.PrintFiles && !opts.DU && !n.IsDir {
+		return entryResult{}
+	}
+
+	entryRel := path.Join(d.rel(), n.Name)
+
+	if !opts.visible(n, entryRel, d.rules) {
+		return entryResult{}
+	}
+
+	if info == nil && (!n.IsDir || opts.SortBy == "mtime" || opts.FollowLinks) {
+		info, err = entry.Info()
+		if err != nil {
+			n.Err = err
+			return w.failed(n)
+		}
+	}
+	if info != nil {
+		n.Mode = info.Mode()
+		n.ModTime = info.ModTime()
+		if !n.IsDir {
+			n.Size = info.Size()
+		}
+	}
+	if opts.showMeta() {
+		linkInfo := info
+		if linkInfo == nil || n.LinkTarget != "" {
+			linkInfo, err = entry.Info()
+			if err != nil {
+				n.Err = err
+				return w.failed(n)
+			}
+		}
+		n.Meta = newMeta(linkInfo)
+	}
+
+	if !n.IsDir {
+		if !opts.PrintFiles {
+			return entryResult{size: n.Size}
+		}
+		return entryResult{node: n, size: n.Size}
+	}
+
+	real := path.Join(d.real, n.Name)
+	if n.LinkTarget != "" {
+		real = resolveLink(d.real, n.LinkTarget)
+	}
+
+	if n.LinkTarget != "" && opts.FollowLinks && isAncestor(info, real, d.ancestors) {
+		n.Recursive = true
+	}
+
+	// каталоги глубже ограничения не выводим, но с DU читаем ради размера
+	expand := opts.MaxDepth == 0 || d.level < opts.MaxDepth
+	follow := !n.Recursive && (n.LinkTarget == "" || opts.FollowLinks)
+	if !follow || !expand && !opts.DU {
+		return entryResult{node: n}
+	}
+
+	sub := dirState{
+		path:  entryPath,
+		real:  real,
+		level: d.level + 1,
+		rules: d.rules,
+	}
+	if opts.FollowLinks {
+		sub.ancestors = append(d.ancestors[:len(d.ancestors):len(d.ancestors)], ancestor{info: info, real: real})
+	}
+
+	// нечитаемый подкаталог не прерывает обход, ошибка выводится в дереве
+	children, size, err := w.readDir(sub)
+	if err != nil {
+		n.Err = err
+		return entryResult{node: n}
+	}
+	if opts.DU {
+		n.Size = size
+	}
+	if expand {
+		if opts.Prune && len(children) == 0 {
+			return entryResult{size: n.Size}
+		}
+		n.Children = children
+	}
+
+	return entryResult{node: n, size: n.Size}
+}
+
+// visible проверяет элемент по шаблонам Include/Exclude и правилам .gitignore
+func (opts Options) visible(n *Node, rel string, rules []ignoreRule) bool {
+
+	if matchAny(opts.Exclude, n.Name) {
+		return false
+	}
+
+	if !n.IsDir && len(opts.Include) > 0 && !matchAny(opts.Include, n.Name) {
+		return false
+	}
+
+	if opts.Gitignore {
+		if n.IsDir && n.Name == ".git" {
+			return false
+		}
+		if ignored(rules, rel, n.IsDir) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
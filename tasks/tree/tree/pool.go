@@ -0,0 +1,38 @@
+package tree
+
+import (
+	"sync"
+)
+
+// pool ограничивает число дополнительных горутин обхода. Если свободных
+// нет, задача выполняется в вызывающей горутине: обход рекурсивный,
+// и ожидание слота внутри задачи могло бы заблокировать все воркеры.
+type pool struct {
+	slots chan struct{}
+}
+
+// newPool возвращает nil при workers <= 1, тогда всё выполняется последовательно.
+// Вызывающая горутина тоже работает, поэтому дополнительных на одну меньше.
+func newPool(workers int) *pool {
+	if workers <= 1 {
+		return nil
+	}
+	return &pool{slots: make(chan struct{}, workers-1)}
+}
+
+func (p *pool) run(wg *sync.WaitGroup, task func()) {
+	if p != nil {
+		select {
+		case p.slots <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-p.slots }()
+				task()
+			}()
+			return
+		default:
+		}
+	}
+	task()
+}
@@ -0,0 +1,105 @@
+package tree
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// latencyFS добавляет задержку к каждому чтению каталога и каждому
+// запросу информации о файле, как на медленном сетевом диске
+type latencyFS struct {
+	fs.FS
+	delay func() time.Duration
+}
+
+func (l latencyFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	time.Sleep(l.delay())
+	entries, err := fs.ReadDir(l.FS, name)
+	for i, entry := range entries {
+		entries[i] = latencyEntry{DirEntry: entry, delay: l.delay}
+	}
+	return entries, err
+}
+
+func (l latencyFS) Stat(name string) (fs.FileInfo, error) {
+	time.Sleep(l.delay())
+	return fs.Stat(l.FS, name)
+}
+
+type latencyEntry struct {
+	fs.DirEntry
+	delay func() time.Duration
+}
+
+func (e latencyEntry) Info() (fs.FileInfo, error) {
+	time.Sleep(e.delay())
+	return e.DirEntry.Info()
+}
+
+// makeWideFS строит дерево глубины depth, где на каждом уровне
+// width каталогов и width файлов
+func makeWideFS(fsys fstest.MapFS, root string, depth, width int) {
+	if depth == 0 {
+		return
+	}
+	for i := 0; i < width; i++ {
+		dir := fmt.Sprintf("%s/dir%d", root, i)
+		if root == "." {
+			dir = fmt.Sprintf("dir%d", i)
+		}
+		fsys[dir+"/file.txt"] = &fstest.MapFile{Data: make([]byte, i)}
+		makeWideFS(fsys, dir, depth-1, width)
+	}
+}
+
+func TestReadConcurrent(t *testing.T) {
+	fsys := latencyFS{
+		FS: testFS,
+		delay: func() time.Duration {
+			return time.Duration(rand.Intn(500)) * time.Microsecond
+		},
+	}
+
+	for _, workers := range []int{2, 4, 16} {
+		out := new(bytes.Buffer)
+		err := Render(out, fsys, Options{PrintFiles: true, Workers: workers})
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if result := out.String(); result != testFullResult {
+			t.Errorf("results not match for %d workers\nGot:\n%v\nExpected:\n%v", workers, result, testFullResult)
+		}
+	}
+}
+
+// -----
+// go test -bench Latency
+
+func BenchmarkReadLatency(b *testing.B) {
+	wide := fstest.MapFS{}
+	makeWideFS(wide, ".", 3, 4)
+	fsys := latencyFS{
+		FS: wide,
+		delay: func() time.Duration {
+			return 200 * time.Microsecond
+		},
+	}
+
+	for _, workers := range []int{1, 4, 16, 64} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				err := Render(io.Discard, fsys, Options{PrintFiles: true, DU: true, Workers: workers})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package tree
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// snapshotVersion - версия формата файла снимка
+const snapshotVersion = 1
+
+// Snapshot - запись дерева для проверки в CI: пути, типы, размеры
+// и, если Hashes, SHA-256 файлов
+type Snapshot struct {
+	Version int             `json:"version"`
+	Hashes  bool            `json:"hashes,omitempty"`
+	Entries []SnapshotEntry `json:"entries"` // по возрастанию Path
+}
+
+// SnapshotEntry - элемент снимка. Size и Hash есть только у файлов,
+// нулевой Size не записывается. Target - только у ссылок.
+type SnapshotEntry struct {
+	Path   string `json:"path"`
+	Type   string `json:"type"` // dir, file или link
+	Size   int64  `json:"size,omitempty"`
+	Target string `json:"target,omitempty"`
+	Hash   string `json:"hash,omitempty"`
+}
+
+// TakeSnapshot записывает дерево, прочитанное из fsys с PrintFiles.
+// С hashes считается SHA-256 каждого файла.
+func TakeSnapshot(fsys fs.FS, root *Node, hashes bool) (Snapshot, error) {
+	s := Snapshot{Version: snapshotVersion, Hashes: hashes, Entries: []SnapshotEntry{}}
+
+	var walk func(nodes []*Node, dir string) error
+	walk = func(nodes []*Node, dir string) error {
+		for _, n := range nodes {
+			e := SnapshotEntry{Path: path.Join(dir, n.Name), Type: "file"}
+			switch {
+			case n.LinkTarget != "":
+				e.Type, e.Target = "link", n.LinkTarget
+			case n.IsDir:
+				e.Type = "dir"
+			default:
+				e.Size = n.Size
+				if hashes {
+					sum, err := hashFile(fsys, e.Path)
+					if err != nil {
+						return err
+					}
+					e.Hash = hex.EncodeToString(sum)
+				}
+			}
+			s.Entries = append(s.Entries, e)
+
+			if err := walk(n.Children, e.Path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(root.Children, ""); err != nil {
+		return Snapshot{}, err
+	}
+
+	sort.Slice(s.Entries, func(i, j int) bool { return s.Entries[i].Path < s.Entries[j].Path })
+	return s, nil
+}
+
+// Write выводит снимок в JSON, по элементу на строку, чтобы изменения
+// снимка хорошо читались в diff
+func (s Snapshot) Write(out io.Writer) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "{\n  \"version\": %d,\n", s.Version)
+	if s.Hashes {
+		b.WriteString("  \"hashes\": true,\n")
+	}
+	b.WriteString("  \"entries\": [")
+	for i, e := range s.Entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n    ")
+		b.Write(line)
+	}
+	if len(s.Entries) > 0 {
+		b.WriteString("\n  ")
+	}
+	b.WriteString("]\n}\n")
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// ReadSnapshot читает снимок, записанный Write
+func ReadSnapshot(r io.Reader) (Snapshot, error) {
+	s := Snapshot{}
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return Snapshot{}, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	if s.Version != snapshotVersion {
+		return Snapshot{}, fmt.Errorf("unsupported snapshot version %d", s.Version)
+	}
+	return s, nil
+}
+
+// Drift - отличие дерева от снимка
+type Drift struct {
+	Path     string
+	Kind     string // missing, unexpected или changed
+	Expected string // для changed - описание элемента в снимке
+	Actual   string // для changed - описание элемента в дереве
+}
+
+func (d Drift) String() string {
+	if d.Kind == "changed" {
+		return fmt.Sprintf("changed: %s: %s -> %s", d.Path, d.Expected, d.Actual)
+	}
+	return d.Kind + ": " + d.Path
+}
+
+// describe - описание элемента для отчёта, хеш сокращается как в git
+func (e SnapshotEntry) describe() string {
+	switch e.Type {
+	case "link":
+		return "link to " + e.Target
+	case "file":
+		result := "file " + formatSize(e.Size, false)
+		if e.Hash != "" {
+			result += " " + e.Hash[:min(len(e.Hash), 12)]
+		}
+		return result
+	}
+	return e.Type
+}
+
+// Verify сравнивает снимок actual с ожидаемым s, результат упорядочен по пути.
+// Хеши сравниваются, только если они есть в обоих снимках.
+func (s Snapshot) Verify(actual Snapshot) []Drift {
+	drift := []Drift{}
+	expected := map[string]SnapshotEntry{}
+	for _, e := range s.Entries {
+		expected[e.Path] = e
+	}
+
+	for _, a := range actual.Entries {
+		e, ok := expected[a.Path]
+		delete(expected, a.Path)
+		if !ok {
+			drift = append(drift, Drift{Path: a.Path, Kind: "unexpected"})
+			continue
+		}
+		if e.Hash == "" || a.Hash == "" {
+			e.Hash, a.Hash = "", ""
+		}
+		if e != a {
+			drift = append(drift, Drift{Path: a.Path, Kind: "changed", Expected: e.describe(), Actual: a.describe()})
+		}
+	}
+	for p := range expected {
+		drift = append(drift, Drift{Path: p, Kind: "missing"})
+	}
+
+	sort.Slice(drift, func(i, j int) bool { return drift[i].Path < drift[j].Path })
+	return drift
+}
@@ -0,0 +1,147 @@
+package tree
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSnapshot(t *testing.T) {
+	fsys := fstest.MapFS{
+		"bin/tool":       {Data: []byte("binary")},
+		"docs/README.md": {Data: []byte("# docs")},
+		"empty.txt":      {},
+		"latest":         {Data: []byte("bin/tool"), Mode: fs.ModeSymlink},
+	}
+	root, err := Read(fsys, ".", Options{PrintFiles: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := TakeSnapshot(fsys, root, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(bytes.Buffer)
+	if err := snapshot.Write(out); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "version": 1,
+  "entries": [
+    {"path":"bin","type":"dir"},
+    {"path":"bin/tool","type":"file","size":6},
+    {"path":"docs","type":"dir"},
+    {"path":"docs/README.md","type":"file","size":6},
+    {"path":"empty.txt","type":"file"},
+    {"path":"latest","type":"link","target":"bin/tool"}
+  ]
+}
+`
+	if out.String() != expected {
+		t.Errorf("snapshot not match\nGot:\n%v\nExpected:\n%v", out, expected)
+	}
+
+	read, err := ReadSnapshot(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, snapshot) {
+		t.Errorf("snapshot changed after reading\nGot:\n%v\nExpected:\n%v", read, snapshot)
+	}
+
+	if _, err := ReadSnapshot(strings.NewReader(`{"version": 2, "entries": []}`)); err == nil {
+		t.Errorf("unsupported version accepted")
+	}
+}
+
+func TestSnapshotVerify(t *testing.T) {
+	before := fstest.MapFS{
+		"a.txt":     {Data: []byte("aaa")},
+		"b.txt":     {Data: []byte("bbb")},
+		"gen/x.go":  {Data: []byte("package x")},
+		"gen/y.go":  {Data: []byte("package y")},
+		"link":      {Data: []byte("a.txt"), Mode: fs.ModeSymlink},
+		"same-size": {Data: []byte("old")},
+	}
+	after := fstest.MapFS{
+		"a.txt":     {Data: []byte("aaaa")},
+		"b.txt":     {Data: []byte("bbb")},
+		"gen/x.go":  {Data: []byte("package x")},
+		"gen/z.go":  {Data: []byte("package z")},
+		"link":      {Data: []byte("b.txt"), Mode: fs.ModeSymlink},
+		"same-size": {Data: []byte("new")},
+	}
+
+	snapshot := func(fsys fs.FS, hashes bool) Snapshot {
+		root, err := Read(fsys, ".", Options{PrintFiles: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := TakeSnapshot(fsys, root, hashes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	drift := snapshot(before, false).Verify(snapshot(after, true))
+	expected := []string{
+		"changed: a.txt: file 3b -> file 4b",
+		"missing: gen/y.go",
+		"unexpected: gen/z.go",
+		"changed: link: link to a.txt -> link to b.txt",
+	}
+	if got := driftStrings(drift); !reflect.DeepEqual(got, expected) {
+		t.Errorf("drift without hashes not match\nGot:\n%v\nExpected:\n%v", got, expected)
+	}
+
+	drift = snapshot(before, true).Verify(snapshot(after, true))
+	if got := driftStrings(drift); len(got) != 5 || got[4] != "changed: same-size: file 3b cba06b5736fa -> file 3b 11507a0e2f5e" {
+		t.Errorf("drift with hashes not match\nGot:\n%v", got)
+	}
+
+	if drift := snapshot(after, true).Verify(snapshot(after, true)); len(drift) != 0 {
+		t.Errorf("drift of the same tree\nGot:\n%v", drift)
+	}
+}
+
+func driftStrings(drift []Drift) []string {
+	result := []string{}
+	for _, d := range drift {
+		result = append(result, d.String())
+	}
+	return result
+}
+
+// TestSnapshotTestdata сверяет testdata из исходного задания, включая
+// содержимое файлов, со снимком testdata/full.json
+func TestSnapshotTestdata(t *testing.T) {
+	file, err := os.Open("testdata/full.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	expected, err := ReadSnapshot(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := os.DirFS("../testdata")
+	root, err := Read(fsys, ".", Options{PrintFiles: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := TakeSnapshot(fsys, root, expected.Hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if drift := expected.Verify(actual); len(drift) > 0 || !expected.Hashes {
+		t.Errorf("testdata differs from snapshot\nGot:\n%v\nExpected:\n%v", drift, []Drift{})
+	}
+}
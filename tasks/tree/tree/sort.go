@@ -0,0 +1,37 @@
+package tree
+
+import (
+	"sort"
+)
+
+// SortModes - допустимые значения Options.SortBy
+var SortModes = []string{"name", "size", "mtime"}
+
+// sortNodes упорядочивает элементы одного каталога. С Reverse обращается порядок
+// ключа сортировки, но каталоги при DirsFirst всё равно идут первыми.
+func sortNodes(nodes []*Node, opts Options) {
+	less := func(a, b *Node) bool {
+		switch opts.SortBy {
+		case "size":
+			if a.Size != b.Size {
+				return a.Size < b.Size
+			}
+		case "mtime":
+			if !a.ModTime.Equal(b.ModTime) {
+				return a.ModTime.Before(b.ModTime)
+			}
+		}
+		return a.Name < b.Name
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		if opts.DirsFirst && a.IsDir != b.IsDir {
+			return a.IsDir
+		}
+		if opts.Reverse {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+}
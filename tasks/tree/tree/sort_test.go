@@ -0,0 +1,90 @@
+package tree
+
+import (
+	"bytes"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestTreeSort(t *testing.T) {
+	now := time.Now()
+	hours := func(n int) time.Time {
+		return now.Add(time.Duration(n) * time.Hour)
+	}
+	fsys := fstest.MapFS{
+		"a.txt":     {Data: []byte("aaaaa"), ModTime: hours(2)},
+		"b":         {Mode: fs.ModeDir, ModTime: hours(4)},
+		"b/c.txt":   {Data: []byte("c")},
+		"b/d.txt":   {Data: []byte("dd")},
+		"e":         {Mode: fs.ModeDir, ModTime: hours(3)},
+		"f.txt":     {Data: []byte("f"), ModTime: hours(0)},
+		"g":         {Mode: fs.ModeDir, ModTime: hours(1)},
+		"g/big.bin": {Data: make([]byte, 3000)},
+	}
+
+	cases := []struct {
+		opts     Options
+		expected string
+	}{
+		{
+			opts: Options{PrintFiles: true, SortBy: "size", MaxDepth: 1},
+			expected: `├───b
+├───e
+├───g
+├───f.txt (1b)
+└───a.txt (5b)
+`,
+		},
+		{
+			opts: Options{PrintFiles: true, SortBy: "size", DU: true, Reverse: true, DirsFirst: true, MaxDepth: 1},
+			expected: `├───g (3000b)
+├───b (3b)
+├───e (empty)
+├───a.txt (5b)
+└───f.txt (1b)
+`,
+		},
+		{
+			opts: Options{PrintFiles: true, SortBy: "mtime", MaxDepth: 1},
+			expected: `├───f.txt (1b)
+├───g
+├───a.txt (5b)
+├───e
+└───b
+`,
+		},
+		{
+			opts: Options{PrintFiles: true, Reverse: true, Human: true, DU: true},
+			expected: `├───g (2.9KiB)
+│	└───big.bin (2.9KiB)
+├───f.txt (1b)
+├───e (empty)
+├───b (3b)
+│	├───d.txt (2b)
+│	└───c.txt (1b)
+└───a.txt (5b)
+`,
+		},
+		{
+			opts: Options{DU: true, DirsFirst: true},
+			expected: `├───b (3b)
+├───e (empty)
+└───g (3000b)
+`,
+		},
+	}
+
+	for _, c := range cases {
+		out := new(bytes.Buffer)
+		err := Render(out, fsys, c.opts)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if result := out.String(); result != c.expected {
+			t.Errorf("results not match for %+v\nGot:\n%v\nExpected:\n%v", c.opts, result, c.expected)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package tree
+
+import (
+	"io/fs"
+)
+
+// fileID - устройство и inode файла, на этой платформе недоступны
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+func getFileID(info fs.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
+
+func getOwner(info fs.FileInfo) (string, string, bool) {
+	return "", "", false
+}
@@ -0,0 +1,32 @@
+//go:build unix
+
+package tree
+
+import (
+	"io/fs"
+	"strconv"
+	"syscall"
+)
+
+// fileID - устройство и inode файла
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+func getFileID(info fs.FileInfo) (fileID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true
+}
+
+// getOwner возвращает uid и gid владельца файла
+func getOwner(info fs.FileInfo) (string, string, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", "", false
+	}
+	return strconv.FormatUint(uint64(stat.Uid), 10), strconv.FormatUint(uint64(stat.Gid), 10), true
+}
@@ -0,0 +1,145 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Stats - сводка по прочитанному дереву для проверок репозитория
+type Stats struct {
+	Dirs       int        `json:"dirs"`
+	Files      int        `json:"files"`
+	Bytes      int64      `json:"bytes"`
+	EmptyFiles int        `json:"empty_files"`
+	Extensions []ExtStats `json:"extensions"` // по убыванию суммарного размера
+	Largest    []FileStat `json:"largest"`    // по убыванию размера
+	Deepest    string     `json:"deepest"`
+	Depth      int        `json:"depth"`
+}
+
+// ExtStats - число и суммарный размер файлов с расширением Ext,
+// пустое Ext - файлы без расширения
+type ExtStats struct {
+	Ext   string `json:"ext"`
+	Files int    `json:"files"`
+	Bytes int64  `json:"bytes"`
+}
+
+// FileStat - файл и его размер
+type FileStat struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// CollectStats подсчитывает сводку по уже прочитанному дереву, top - сколько
+// самых больших файлов запомнить. Файлы попадают в сводку, только если дерево
+// прочитано с PrintFiles.
+func CollectStats(root *Node, top int) Stats {
+	s := Stats{Extensions: []ExtStats{}, Largest: []FileStat{}}
+	extensions := map[string]*ExtStats{}
+
+	var walk func(nodes []*Node, dir string, depth int)
+	walk = func(nodes []*Node, dir string, depth int) {
+		for _, n := range nodes {
+			name := path.Join(dir, n.Name)
+			if depth > s.Depth {
+				s.Deepest, s.Depth = name, depth
+			}
+
+			if n.IsDir {
+				s.Dirs++
+				walk(n.Children, name, depth+1)
+				continue
+			}
+
+			s.Files++
+			s.Bytes += n.Size
+			if n.Size == 0 && !n.Broken {
+				s.EmptyFiles++
+			}
+
+			ext := extension(n.Name)
+			if extensions[ext] == nil {
+				extensions[ext] = &ExtStats{Ext: ext}
+			}
+			extensions[ext].Files++
+			extensions[ext].Bytes += n.Size
+
+			s.Largest = addLargest(s.Largest, FileStat{Path: name, Size: n.Size}, top)
+		}
+	}
+	walk(root.Children, "", 1)
+
+	for _, ext := range extensions {
+		s.Extensions = append(s.Extensions, *ext)
+	}
+	sort.Slice(s.Extensions, func(i, j int) bool {
+		a, b := s.Extensions[i], s.Extensions[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return a.Ext < b.Ext
+	})
+
+	return s
+}
+
+// extension возвращает расширение файла, у имён вида .gitignore его нет
+func extension(name string) string {
+	ext := path.Ext(name)
+	if ext == name {
+		return ""
+	}
+	return strings.ToLower(ext)
+}
+
+// addLargest вставляет файл в список самых больших, в котором не больше top элементов.
+// При равных размерах раньше остаётся тот, что встретился раньше.
+func addLargest(largest []FileStat, f FileStat, top int) []FileStat {
+	i := sort.Search(len(largest), func(i int) bool { return largest[i].Size < f.Size })
+	if i >= top {
+		return largest
+	}
+	if len(largest) < top {
+		largest = append(largest, FileStat{})
+	}
+	copy(largest[i+1:], largest[i:])
+	largest[i] = f
+	return largest
+}
+
+// WriteText выводит сводку таблицей, с human размеры в KiB, MiB, ...
+func (s Stats) WriteText(out io.Writer, human bool) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, "extension\tfiles\tsize\n")
+	for _, ext := range s.Extensions {
+		name := ext.Ext
+		if name == "" {
+			name = "(none)"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\n", name, ext.Files, formatSize(ext.Bytes, human))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "\n%s, %s, %d empty, %s total\n",
+		Plural(s.Dirs, "directory", "directories"), Plural(s.Files, "file", "files"), s.EmptyFiles, formatSize(s.Bytes, human))
+	if s.Deepest != "" {
+		fmt.Fprintf(out, "deepest: %s (%s)\n", s.Deepest, Plural(s.Depth, "level", "levels"))
+	}
+
+	if len(s.Largest) == 0 {
+		return nil
+	}
+	fmt.Fprint(out, "largest:\n")
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, f := range s.Largest {
+		fmt.Fprintf(w, "  %s\t%s\n", formatSize(f.Size, human), f.Path)
+	}
+	return w.Flush()
+}
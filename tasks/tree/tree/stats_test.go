@@ -0,0 +1,74 @@
+package tree
+
+import (
+	"bytes"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCollectStats(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore":         {Data: []byte("bin\n")},
+		"Makefile":           {Data: []byte("all:\n")},
+		"main.go":            {Data: make([]byte, 300)},
+		"docs/README.md":     {Data: make([]byte, 1500)},
+		"docs/empty.md":      {},
+		"pkg/a/b/deep.GO":    {Data: make([]byte, 100)},
+		"pkg/a/b/skip.txt":   {Data: []byte("skip")},
+		"pkg/a/util.go":      {Data: make([]byte, 300)},
+		"pkg/a/b/c":          {Mode: fs.ModeDir},
+		"assets/logo.tar.gz": {Data: make([]byte, 2000)},
+	}
+
+	root, err := Read(fsys, ".", Options{PrintFiles: true, Exclude: []string{"*.txt"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := CollectStats(root, 3)
+
+	expected := Stats{
+		Dirs:       6,
+		Files:      8,
+		Bytes:      4209,
+		EmptyFiles: 1,
+		Extensions: []ExtStats{
+			{Ext: ".gz", Files: 1, Bytes: 2000},
+			{Ext: ".md", Files: 2, Bytes: 1500},
+			{Ext: ".go", Files: 3, Bytes: 700},
+			{Ext: "", Files: 2, Bytes: 9},
+		},
+		Largest: []FileStat{
+			{Path: "assets/logo.tar.gz", Size: 2000},
+			{Path: "docs/README.md", Size: 1500},
+			{Path: "main.go", Size: 300},
+		},
+		Deepest: "pkg/a/b/c",
+		Depth:   4,
+	}
+	if !reflect.DeepEqual(stats, expected) {
+		t.Errorf("stats not match\nGot:\n%+v\nExpected:\n%+v", stats, expected)
+	}
+
+	out := new(bytes.Buffer)
+	if err := stats.WriteText(out, false); err != nil {
+		t.Fatal(err)
+	}
+	expectedText := `extension  files  size
+.gz        1      2000b
+.md        2      1500b
+.go        3      700b
+(none)     2      9b
+
+6 directories, 8 files, 1 empty, 4209b total
+deepest: pkg/a/b/c (4 levels)
+largest:
+  2000b  assets/logo.tar.gz
+  1500b  docs/README.md
+  300b   main.go
+`
+	if out.String() != expectedText {
+		t.Errorf("text not match\nGot:\n%v\nExpected:\n%v", out, expectedText)
+	}
+}
@@ -0,0 +1,62 @@
+package tree
+
+import (
+	"strings"
+)
+
+// Style - строки, которыми TextFormatter рисует связи между элементами
+type Style struct {
+	Branch   string // перед элементом, за которым в каталоге есть ещё
+	Last     string // перед последним элементом каталога
+	Vertical string // отступ потомков непоследнего элемента
+	Space    string // отступ потомков последнего элемента
+}
+
+// Glyphs - набор символов, из которого строится Style любой ширины
+type Glyphs struct {
+	Tee    string // развилка, ├
+	Corner string // угол, └
+	Pipe   string // вертикаль, │
+	Dash   string // горизонталь, ─
+	Gap    string // между связью и именем
+	Width  int    // ширина по умолчанию, 0 - исходный вид с табуляцией
+}
+
+// GlyphSets - наборы символов по именам
+var GlyphSets = map[string]Glyphs{
+	"unicode": {Tee: "├", Corner: "└", Pipe: "│", Dash: "─"},
+	"ascii":   {Tee: "|", Corner: "`", Pipe: "|", Dash: "-", Gap: " ", Width: 4},
+	"compact": {Tee: "├", Corner: "└", Pipe: "│", Dash: "─", Width: 2},
+}
+
+// DefaultStyle - вид вывода, если не задан Options.Style
+var DefaultStyle = GlyphSets["unicode"].Style(0)
+
+// Style строит стиль с отступом в width колонок, 0 - ширина набора.
+// Связи дополняются горизонталью до ширины отступа, вложенность - пробелами.
+func (g Glyphs) Style(width int) Style {
+	if width == 0 {
+		width = g.Width
+	}
+	if width == 0 {
+		return Style{
+			Branch:   g.Tee + strings.Repeat(g.Dash, 3) + g.Gap,
+			Last:     g.Corner + strings.Repeat(g.Dash, 3) + g.Gap,
+			Vertical: g.Pipe + "\t",
+			Space:    "\t",
+		}
+	}
+
+	// в узкий отступ промежуток не помещается
+	gap := g.Gap
+	if width-1 < len(gap) {
+		gap = ""
+	}
+	dashes := width - 1 - len(gap)
+	return Style{
+		Branch:   g.Tee + strings.Repeat(g.Dash, dashes) + gap,
+		Last:     g.Corner + strings.Repeat(g.Dash, dashes) + gap,
+		Vertical: g.Pipe + strings.Repeat(" ", width-1),
+		Space:    strings.Repeat(" ", width),
+	}
+}
@@ -0,0 +1,70 @@
+package tree
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTreeStyle(t *testing.T) {
+	cases := []struct {
+		glyphs   string
+		width    int
+		expected string
+	}{
+		{
+			glyphs: "ascii",
+			expected: "|-- dynamic\n" +
+				"|   |-- a.txt (1b)\n" +
+				"|   `-- b.txt (2b)\n" +
+				"`-- static\n" +
+				"    `-- c.txt (3b)\n",
+		},
+		{
+			glyphs: "compact",
+			expected: "├─dynamic\n" +
+				"│ ├─a.txt (1b)\n" +
+				"│ └─b.txt (2b)\n" +
+				"└─static\n" +
+				"  └─c.txt (3b)\n",
+		},
+		{
+			glyphs: "unicode",
+			width:  3,
+			expected: "├──dynamic\n" +
+				"│  ├──a.txt (1b)\n" +
+				"│  └──b.txt (2b)\n" +
+				"└──static\n" +
+				"   └──c.txt (3b)\n",
+		},
+		{
+			glyphs: "ascii",
+			width:  1,
+			expected: "|dynamic\n" +
+				"||a.txt (1b)\n" +
+				"|`b.txt (2b)\n" +
+				"`static\n" +
+				" `c.txt (3b)\n",
+		},
+	}
+
+	fsys := fstest.MapFS{
+		"dynamic/a.txt": {Data: []byte("a")},
+		"dynamic/b.txt": {Data: []byte("bb")},
+		"static/c.txt":  {Data: []byte("ccc")},
+	}
+	for _, c := range cases {
+		style := GlyphSets[c.glyphs].Style(c.width)
+		out := new(bytes.Buffer)
+		if err := Render(out, fsys, Options{PrintFiles: true, Style: &style}); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != c.expected {
+			t.Errorf("%s with width %d not match\nGot:\n%v\nExpected:\n%v", c.glyphs, c.width, out, c.expected)
+		}
+	}
+
+	if DefaultStyle != (Style{"├───", "└───", "│\t", "\t"}) {
+		t.Errorf("default style changed\nGot:\n%q", DefaultStyle)
+	}
+}
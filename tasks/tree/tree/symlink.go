@@ -0,0 +1,39 @@
+package tree
+
+import (
+	"io/fs"
+	"os"
+	"path"
+)
+
+// resolveLink возвращает путь цели ссылки, лежащей в каталоге dir
+func resolveLink(dir, target string) string {
+	if path.IsAbs(target) {
+		return path.Clean(target)
+	}
+	return path.Join(dir, target)
+}
+
+// isAncestor проверяет, совпадает ли каталог с одним из каталогов на пути
+// от корня. Сравниваются устройство и inode, а если fs.FS их не отдаёт -
+// пути с раскрытыми ссылками.
+func isAncestor(info fs.FileInfo, real string, ancestors []ancestor) bool {
+	for _, a := range ancestors {
+		if sameFile(info, real, a) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameFile(info fs.FileInfo, real string, a ancestor) bool {
+	id, ok := getFileID(info)
+	ancestorID, ancestorOK := getFileID(a.info)
+	if ok && ancestorOK {
+		return id == ancestorID
+	}
+	if os.SameFile(info, a.info) {
+		return true
+	}
+	return real == a.real
+}
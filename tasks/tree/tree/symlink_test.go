@@ -0,0 +1,176 @@
+package tree
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func makeSymlinkFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.CopyFS(root, fstest.MapFS{"a/x.txt": {Data: []byte("xxx")}}); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"a/loop":   "..",
+		"b":        "a",
+		"broken":   "nowhere",
+		"link.txt": "a/x.txt",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Skipf("symlinks are not supported: %v", err)
+		}
+	}
+	return root
+}
+
+func TestTreeSymlinks(t *testing.T) {
+	root := makeSymlinkFixture(t)
+
+	cases := []struct {
+		opts     Options
+		expected string
+	}{
+		{
+			opts: Options{PrintFiles: true},
+			expected: `├───a
+│	├───loop -> ..
+│	└───x.txt (3b)
+├───b -> a
+├───broken -> nowhere
+└───link.txt -> a/x.txt (3b)
+`,
+		},
+		{
+			opts: Options{PrintFiles: true, FollowLinks: true},
+			expected: `├───a
+│	├───loop -> .. [recursive, not followed]
+│	└───x.txt (3b)
+├───b -> a
+│	├───loop -> .. [recursive, not followed]
+│	└───x.txt (3b)
+├───broken -> nowhere
+└───link.txt -> a/x.txt (3b)
+`,
+		},
+		{
+			opts: Options{DU: true},
+			expected: `├───a (3b)
+│	└───loop -> ..
+└───b -> a
+`,
+		},
+		{
+			opts: Options{FollowLinks: true, DU: true},
+			expected: `├───a (3b)
+│	└───loop -> .. [recursive, not followed]
+└───b -> a (3b)
+	└───loop -> .. [recursive, not followed]
+`,
+		},
+	}
+
+	for _, c := range cases {
+		out := new(bytes.Buffer)
+		err := Render(out, os.DirFS(root), c.opts)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if result := out.String(); result != c.expected {
+			t.Errorf("results not match for %+v\nGot:\n%v\nExpected:\n%v", c.opts, result, c.expected)
+		}
+	}
+}
+
+func TestTreeSymlinkJSON(t *testing.T) {
+	root := makeSymlinkFixture(t)
+
+	opts := Options{FollowLinks: true, Format: "json"}
+	tree, err := Read(os.DirFS(root), "a", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := new(bytes.Buffer)
+	if err := Format(out, tree, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{
+  "name": "a",
+  "type": "directory",
+  "children": [
+    {
+      "name": "loop",
+      "type": "directory",
+      "target": "..",
+      "children": [
+        {
+          "name": "a",
+          "type": "directory",
+          "children": [
+            {
+              "name": "loop",
+              "type": "directory",
+              "target": "..",
+              "recursive": true
+            }
+          ]
+        },
+        {
+          "name": "b",
+          "type": "directory",
+          "target": "a",
+          "recursive": true
+        }
+      ]
+    }
+  ]
+}
+`
+	if result := out.String(); result != expected {
+		t.Errorf("results not match\nGot:\n%v\nExpected:\n%v", result, expected)
+	}
+}
+
+func TestTreeSymlinkLoopMapFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/x.txt":  {Data: []byte("xxx")},
+		"a/up":     {Mode: fs.ModeSymlink, Data: []byte("..")},
+		"a/self":   {Mode: fs.ModeSymlink, Data: []byte(".")},
+		"b":        {Mode: fs.ModeSymlink, Data: []byte("a")},
+		"c/d":      {Mode: fs.ModeSymlink, Data: []byte("../a")},
+		"c/e.txt":  {},
+		"link.txt": {Mode: fs.ModeSymlink, Data: []byte("c/e.txt")},
+	}
+
+	expected := `├───a
+│	├───self -> . [recursive, not followed]
+│	├───up -> .. [recursive, not followed]
+│	└───x.txt (3b)
+├───b -> a
+│	├───self -> . [recursive, not followed]
+│	├───up -> .. [recursive, not followed]
+│	└───x.txt (3b)
+├───c
+│	├───d -> ../a
+│	│	├───self -> . [recursive, not followed]
+│	│	├───up -> .. [recursive, not followed]
+│	│	└───x.txt (3b)
+│	└───e.txt (empty)
+└───link.txt -> c/e.txt (empty)
+`
+
+	out := new(bytes.Buffer)
+	if err := Render(out, fsys, Options{PrintFiles: true, FollowLinks: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result := out.String(); result != expected {
+		t.Errorf("results not match\nGot:\n%v\nExpected:\n%v", result, expected)
+	}
+}
@@ -0,0 +1,87 @@
+// Package tree читает иерархию каталогов из любой fs.FS и выводит её
+// в виде дерева: текстом с псевдографикой, JSON, XML, YAML или HTML.
+package tree
+
+import (
+	"html/template"
+	"io"
+	"io/fs"
+)
+
+// Options - параметры обхода и вывода
+type Options struct {
+	PrintFiles  bool
+	MaxDepth    int // 0 - без ограничения
+	Prune       bool
+	Include     []string // показывать только файлы, подходящие под шаблоны
+	Exclude     []string // скрывать файлы и каталоги, подходящие под шаблоны
+	Gitignore   bool
+	Format      string // ключ Formatters, по умолчанию text
+	Human       bool   // размеры в KiB, MiB, ...
+	DU          bool   // у каталогов выводится суммарный размер
+	SortBy      string // name, size или mtime, по умолчанию name
+	DirsFirst   bool
+	Reverse     bool
+	FollowLinks bool // раскрывать ссылки на каталоги
+	ShowMode    bool
+	ShowUser    bool
+	ShowGroup   bool
+	ShowTime    bool
+	ShowInode   bool
+	TimeFormat  string
+	Workers     int     // число параллельных чтений, 0 или 1 - последовательно
+	Colors      Palette // цвета имён в текстовом выводе, nil - без цвета
+	Style       *Style  // связи в текстовом выводе, nil - DefaultStyle
+
+	BaseURL      string             // начало ссылок в формате html
+	HTMLTemplate *template.Template // шаблон формата html, nil - DefaultHTMLTemplate
+
+	OnlyChanges    bool  // Diff оставляет только отличающиеся элементы
+	CompareContent bool  // Diff сравнивает содержимое файлов одного размера
+	GrepMaxSize    int64 // Grep пропускает файлы больше, 0 - без ограничения
+}
+
+// Read читает дерево с корнем root внутри fsys. Ошибка возвращается, только если
+// не удалось прочитать сам root, ошибки подкаталогов сохраняются в Node.Err.
+// Имя корневого узла - root.
+func Read(fsys fs.FS, root string, opts Options) (*Node, error) {
+	d := dirState{path: root, real: root, level: 1}
+	if opts.FollowLinks {
+		info, err := fs.Stat(fsys, root)
+		if err != nil {
+			return nil, err
+		}
+		d.ancestors = []ancestor{{info: info, real: root}}
+	}
+
+	w := &walker{fsys: fsys, opts: opts, pool: newPool(opts.Workers)}
+	nodes, size, err := w.readDir(d)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &Node{Name: root, IsDir: true, Children: nodes}
+	if opts.DU {
+		tree.Size = size
+	}
+
+	return tree, nil
+}
+
+// Format выводит прочитанное дерево в формате opts.Format
+func Format(out io.Writer, tree *Node, opts Options) error {
+	f, ok := Formatters[opts.Format]
+	if !ok {
+		f = TextFormatter{}
+	}
+	return f.Format(out, tree, opts)
+}
+
+// Render читает всю fsys и выводит её дерево
+func Render(out io.Writer, fsys fs.FS, opts Options) error {
+	tree, err := Read(fsys, ".", opts)
+	if err != nil {
+		return err
+	}
+	return Format(out, tree, opts)
+}
@@ -0,0 +1,400 @@
+package tree
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"testing"
+	"testing/fstest"
+)
+
+// testFS повторяет testdata из исходного задания, размеры файлов те же
+var testFS = fstest.MapFS{
+	"project/file.txt":                {Data: make([]byte, 19)},
+	"project/gopher.png":              {Data: make([]byte, 70372)},
+	"static/a_lorem/dolor.txt":        {},
+	"static/a_lorem/gopher.png":       {Data: make([]byte, 70372)},
+	"static/a_lorem/ipsum/gopher.png": {Data: make([]byte, 70372)},
+	"static/css/body.css":             {Data: make([]byte, 28)},
+	"static/empty.txt":                {},
+	"static/html/index.html":          {Data: make([]byte, 57)},
+	"static/js/site.js":               {Data: make([]byte, 10)},
+	"static/z_lorem/dolor.txt":        {},
+	"static/z_lorem/gopher.png":       {Data: make([]byte, 70372)},
+	"static/z_lorem/ipsum/gopher.png": {Data: make([]byte, 70372)},
+	"zline/empty.txt":                 {},
+	"zline/lorem/dolor.txt":           {},
+	"zline/lorem/gopher.png":          {Data: make([]byte, 70372)},
+	"zline/lorem/ipsum/gopher.png":    {Data: make([]byte, 70372)},
+	"zzfile.txt":                      {},
+}
+
+const testFullResult = `├───project
+│	├───file.txt (19b)
+│	└───gopher.png (70372b)
+├───static
+│	├───a_lorem
+│	│	├───dolor.txt (empty)
+│	│	├───gopher.png (70372b)
+│	│	└───ipsum
+│	│		└───gopher.png (70372b)
+│	├───css
+│	│	└───body.css (28b)
+│	├───empty.txt (empty)
+│	├───html
+│	│	└───index.html (57b)
+│	├───js
+│	│	└───site.js (10b)
+│	└───z_lorem
+│		├───dolor.txt (empty)
+│		├───gopher.png (70372b)
+│		└───ipsum
+│			└───gopher.png (70372b)
+├───zline
+│	├───empty.txt (empty)
+│	└───lorem
+│		├───dolor.txt (empty)
+│		├───gopher.png (70372b)
+│		└───ipsum
+│			└───gopher.png (70372b)
+└───zzfile.txt (empty)
+`
+
+func TestTreeFull(t *testing.T) {
+	out := new(bytes.Buffer)
+	err := Render(out, testFS, Options{PrintFiles: true})
+	if err != nil {
+		t.Errorf("test for OK Failed - error")
+	}
+	result := out.String()
+	if result != testFullResult {
+		t.Errorf("test for OK Failed - results not match\nGot:\n%v\nExpected:\n%v", result, testFullResult)
+	}
+}
+
+const testDirResult = `├───project
+├───static
+│	├───a_lorem
+│	│	└───ipsum
+│	├───css
+│	├───html
+│	├───js
+│	└───z_lorem
+│		└───ipsum
+└───zline
+	└───lorem
+		└───ipsum
+`
+
+func TestTreeDir(t *testing.T) {
+	out := new(bytes.Buffer)
+	err := Render(out, testFS, Options{})
+	if err != nil {
+		t.Errorf("test for OK Failed - error")
+	}
+	result := out.String()
+	if result != testDirResult {
+		t.Errorf("test for OK Failed - results not match\nGot:\n%v\nExpected:\n%v", result, testDirResult)
+	}
+}
+
+const testDepthResult = `├───project
+│	├───file.txt (19b)
+│	└───gopher.png (70372b)
+├───static
+│	├───a_lorem
+│	├───css
+│	├───empty.txt (empty)
+│	├───html
+│	├───js
+│	└───z_lorem
+├───zline
+│	├───empty.txt (empty)
+│	└───lorem
+└───zzfile.txt (empty)
+`
+
+func TestTreeDepth(t *testing.T) {
+	out := new(bytes.Buffer)
+	err := Render(out, testFS, Options{PrintFiles: true, MaxDepth: 2})
+	if err != nil {
+		t.Errorf("test for OK Failed - error")
+	}
+	result := out.String()
+	if result != testDepthResult {
+		t.Errorf("test for OK Failed - results not match\nGot:\n%v\nExpected:\n%v", result, testDepthResult)
+	}
+}
+
+func TestTreePrune(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/b":     {Mode: fs.ModeDir},
+		"c/d.txt": {Data: []byte("ddd")},
+		"e":       {Mode: fs.ModeDir},
+		"f/g/h":   {},
+		"z":       {Mode: fs.ModeDir},
+	}
+
+	cases := []struct {
+		opts     Options
+		expected string
+	}{
+		{
+			opts: Options{PrintFiles: true, Prune: true},
+			expected: `├───c
+│	└───d.txt (3b)
+└───f
+	└───g
+		└───h (empty)
+`,
+		},
+		{
+			opts:     Options{Prune: true},
+			expected: ``,
+		},
+		{
+			opts: Options{Prune: true, MaxDepth: 1},
+			expected: `├───a
+├───c
+├───e
+├───f
+└───z
+`,
+		},
+		{
+			opts: Options{Prune: true, MaxDepth: 2},
+			expected: `├───a
+│	└───b
+└───f
+	└───g
+`,
+		},
+	}
+
+	for _, c := range cases {
+		out := new(bytes.Buffer)
+		err := Render(out, fsys, c.opts)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if result := out.String(); result != c.expected {
+			t.Errorf("results not match for %+v\nGot:\n%v\nExpected:\n%v", c.opts, result, c.expected)
+		}
+	}
+}
+
+func TestTreeFilter(t *testing.T) {
+	cases := []struct {
+		opts     Options
+		expected string
+	}{
+		{
+			opts: Options{PrintFiles: true, Include: []string{"*.png"}, Exclude: []string{"z*"}},
+			expected: `├───project
+│	└───gopher.png (70372b)
+└───static
+	├───a_lorem
+	│	├───gopher.png (70372b)
+	│	└───ipsum
+	│		└───gopher.png (70372b)
+	├───css
+	├───html
+	└───js
+`,
+		},
+		{
+			opts: Options{PrintFiles: true, Include: []string{"*.png"}, Exclude: []string{"z*", "ipsum"}, Prune: true},
+			expected: `├───project
+│	└───gopher.png (70372b)
+└───static
+	└───a_lorem
+		└───gopher.png (70372b)
+`,
+		},
+		{
+			opts: Options{Exclude: []string{"static", "project"}},
+			expected: `└───zline
+	└───lorem
+		└───ipsum
+`,
+		},
+	}
+
+	for _, c := range cases {
+		out := new(bytes.Buffer)
+		err := Render(out, testFS, c.opts)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if result := out.String(); result != c.expected {
+			t.Errorf("results not match for %+v\nGot:\n%v\nExpected:\n%v", c.opts, result, c.expected)
+		}
+	}
+}
+
+func TestTreeUnreadableDir(t *testing.T) {
+	fsys := failingFS{FS: testFS, fail: "static/a_lorem"}
+
+	expected := `├───a_lorem [error opening dir]
+├───css
+├───html
+├───js
+└───z_lorem
+	└───ipsum
+`
+	tree, err := Read(fsys, "static", Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := new(bytes.Buffer)
+	if err := Format(out, tree, Options{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result := out.String(); result != expected {
+		t.Errorf("results not match\nGot:\n%v\nExpected:\n%v", result, expected)
+	}
+
+	if !errors.Is(tree.Children[0].Err, fs.ErrPermission) {
+		t.Errorf("wrong error: %v", tree.Children[0].Err)
+	}
+
+	if _, err := Read(fsys, "static/a_lorem", Options{}); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("wrong root error: %v", err)
+	}
+}
+
+// failingFS не даёт прочитать каталог или файл fail
+type failingFS struct {
+	fs.FS
+	fail string
+}
+
+func (f failingFS) Open(name string) (fs.File, error) {
+	if name == f.fail {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return f.FS.Open(name)
+}
+
+func (f failingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == f.fail {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrPermission}
+	}
+	return fs.ReadDir(f.FS, name)
+}
+
+// infoFailingFS не даёт узнать FileInfo элемента fail
+type infoFailingFS struct {
+	fs.FS
+	fail string
+}
+
+func (f infoFailingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(f.FS, name)
+	for i, entry := range entries {
+		if path.Join(name, entry.Name()) == f.fail {
+			entries[i] = failingEntry{entry}
+		}
+	}
+	return entries, err
+}
+
+type failingEntry struct {
+	fs.DirEntry
+}
+
+func (e failingEntry) Info() (fs.FileInfo, error) {
+	return nil, &fs.PathError{Op: "lstat", Path: e.Name(), Err: fs.ErrPermission}
+}
+
+func TestTreeUnreadableEntry(t *testing.T) {
+	fsys := infoFailingFS{FS: testFS, fail: "static/css/body.css"}
+
+	expected := `├───css
+│	└───body.css [error reading file]
+├───empty.txt (empty)
+├───html
+│	└───index.html (57b)
+└───js
+	└───site.js (10b)
+`
+	opts := Options{PrintFiles: true, Exclude: []string{"*lorem"}}
+	tree, err := Read(fsys, "static", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := new(bytes.Buffer)
+	if err := Format(out, tree, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result := out.String(); result != expected {
+		t.Errorf("results not match\nGot:\n%v\nExpected:\n%v", result, expected)
+	}
+	if err := tree.Children[0].Children[0].Err; !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestRenderZip(t *testing.T) {
+	buf := new(bytes.Buffer)
+	archive := zip.NewWriter(buf)
+	for name, content := range map[string]string{
+		"docs/readme.md": "# readme",
+		"main.go":        "package main",
+		"readme.md":      "docs/readme.md",
+	} {
+		header := &zip.FileHeader{Name: name}
+		if name == "readme.md" {
+			// zip.Reader не умеет читать ссылки, но это не мешает вывести остальное
+			header.SetMode(fs.ModeSymlink | 0777)
+		}
+		w, err := archive.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `├───docs
+│	└───readme.md (8b)
+├───main.go (12b)
+└───readme.md
+`
+	out := new(bytes.Buffer)
+	if err := Render(out, fsys, Options{PrintFiles: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result := out.String(); result != expected {
+		t.Errorf("results not match\nGot:\n%v\nExpected:\n%v", result, expected)
+	}
+}
+
+func ExampleRender() {
+	fsys := fstest.MapFS{
+		"cmd/main.go":    {Data: []byte("package main")},
+		"go.mod":         {Data: []byte("module example")},
+		"internal/.keep": {},
+	}
+	Render(os.Stdout, fsys, Options{PrintFiles: true})
+	// Output:
+	// ├───cmd
+	// │	└───main.go (12b)
+	// ├───go.mod (14b)
+	// └───internal
+	// 	└───.keep (empty)
+}
@@ -0,0 +1,136 @@
+package tree
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"maps"
+	"sync"
+	"time"
+)
+
+// Watcher сообщает об изменениях под корнем дерева. Одно значение в Changes
+// может означать сразу несколько изменений: подробности не передаются,
+// дерево всё равно читается заново.
+type Watcher interface {
+	Changes() <-chan struct{}
+	Close() error
+}
+
+// errWatcherStopped - канал Changes закрылся раньше, чем отменили контекст
+var errWatcherStopped = errors.New("watcher stopped")
+
+// Watch вызывает render сразу и после каждой серии изменений, о которых сообщает w.
+// Изменения, между которыми прошло меньше debounce, приводят к одной перерисовке.
+// Возвращает nil после отмены ctx или первую ошибку render.
+func Watch(ctx context.Context, w Watcher, debounce time.Duration, render func() error) error {
+
+	if err := render(); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-w.Changes():
+			if !ok {
+				return errWatcherStopped
+			}
+			timer.Reset(debounce)
+		case <-timer.C:
+			if err := render(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// notify сообщает об изменении, не дожидаясь читателя: непрочитанное
+// уведомление уже означает, что дерево надо перечитать
+func notify(changes chan struct{}) {
+	select {
+	case changes <- struct{}{}:
+	default:
+	}
+}
+
+// PollWatcher раз в интервал обходит fsys и сравнивает размеры, режимы
+// и время изменения всех элементов. Работает с любой fs.FS.
+type PollWatcher struct {
+	changes chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+type pollState struct {
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// NewPollWatcher запоминает текущее состояние fsys и начинает опрос
+func NewPollWatcher(fsys fs.FS, interval time.Duration) *PollWatcher {
+	w := &PollWatcher{
+		changes: make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go w.poll(fsys, interval, snapshot(fsys))
+	return w
+}
+
+func (w *PollWatcher) poll(fsys fs.FS, interval time.Duration, last map[string]pollState) {
+	defer close(w.done)
+	defer close(w.changes)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			current := snapshot(fsys)
+			if !maps.Equal(current, last) {
+				last = current
+				notify(w.changes)
+			}
+		}
+	}
+}
+
+// snapshot собирает состояние всех элементов, ошибки чтения тоже
+// считаются состоянием: исчезнувший каталог заметен по пропаже элементов
+func snapshot(fsys fs.FS) map[string]pollState {
+	state := map[string]pollState{}
+	fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		state[name] = pollState{size: info.Size(), mode: info.Mode(), modTime: info.ModTime()}
+		return nil
+	})
+	return state
+}
+
+func (w *PollWatcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Close останавливает опрос и дожидается его завершения
+func (w *PollWatcher) Close() error {
+	w.once.Do(func() { close(w.stop) })
+	<-w.done
+	return nil
+}
@@ -0,0 +1,126 @@
+package tree
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask - события, после которых дерево надо перечитать
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY | syscall.IN_ATTRIB |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+// NotifyWatcher следит за каталогом root и всеми его подкаталогами через inotify.
+// Новые подкаталоги добавляются в наблюдение по мере появления.
+type NotifyWatcher struct {
+	file    *os.File
+	dirs    map[int32]string // каталоги по дескрипторам наблюдения
+	changes chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewNotifyWatcher начинает наблюдение за каталогом root в файловой системе ОС
+func NewNotifyWatcher(root string) (Watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+
+	// неблокирующий дескриптор попадает в poller, и Close прерывает Read
+	w := &NotifyWatcher{
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		dirs:    map[int32]string{},
+		changes: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	if err := w.addTree(fd, root); err != nil {
+		w.file.Close()
+		return nil, err
+	}
+
+	go w.read(fd)
+	return w, nil
+}
+
+// addTree добавляет в наблюдение каталог dir со всеми подкаталогами.
+// Исчезнувшие за время обхода подкаталоги пропускаются.
+func (w *NotifyWatcher) addTree(fd int, dir string) error {
+	return filepath.WalkDir(dir, func(name string, entry os.DirEntry, err error) error {
+		if err != nil {
+			if name == dir {
+				return err
+			}
+			return nil
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		wd, err := syscall.InotifyAddWatch(fd, name, inotifyMask)
+		if err != nil {
+			if name == dir {
+				return &os.PathError{Op: "inotify_add_watch", Path: name, Err: err}
+			}
+			return nil
+		}
+		w.dirs[int32(wd)] = name
+		return nil
+	})
+}
+
+func (w *NotifyWatcher) read(fd int) {
+	defer close(w.done)
+	defer close(w.changes)
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+			if event.Mask&syscall.IN_IGNORED != 0 {
+				delete(w.dirs, event.Wd)
+				continue
+			}
+
+			// новый каталог мог успеть наполниться до того, как за ним начали следить,
+			// поэтому он добавляется целиком
+			dir, ok := w.dirs[event.Wd]
+			if ok && event.Mask&syscall.IN_ISDIR != 0 && event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+				w.addTree(fd, filepath.Join(dir, cString(nameBytes)))
+			}
+
+			notify(w.changes)
+		}
+	}
+}
+
+// cString обрезает имя из события по первому нулевому байту
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+func (w *NotifyWatcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Close прекращает наблюдение и дожидается завершения чтения событий
+func (w *NotifyWatcher) Close() error {
+	var err error
+	w.once.Do(func() { err = w.file.Close() })
+	<-w.done
+	return err
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package tree
+
+import (
+	"errors"
+)
+
+// NewNotifyWatcher доступен только в Linux, в остальных системах
+// вместо него используется NewPollWatcher
+func NewNotifyWatcher(root string) (Watcher, error) {
+	return nil, errors.New("inotify is not supported on this system")
+}
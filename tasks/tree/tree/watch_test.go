@@ -0,0 +1,114 @@
+package tree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// chanWatcher - Watcher, изменениями которого управляет тест
+type chanWatcher chan struct{}
+
+func (w chanWatcher) Changes() <-chan struct{} { return w }
+func (w chanWatcher) Close() error             { return nil }
+
+func TestWatchDebounce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chanWatcher)
+	renders := make(chan int, 10)
+	count := 0
+
+	done := make(chan error)
+	go func() {
+		done <- Watch(ctx, changes, 50*time.Millisecond, func() error {
+			count++
+			renders <- count
+			return nil
+		})
+	}()
+
+	<-renders
+	for i := 0; i < 5; i++ {
+		changes <- struct{}{}
+	}
+	if got := <-renders; got != 2 {
+		t.Errorf("burst not debounced\nGot:\n%v\nExpected:\n%v", got, 2)
+	}
+
+	select {
+	case got := <-renders:
+		t.Errorf("unexpected render %d after burst", got)
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("watch failed: %v", err)
+	}
+
+	close(changes)
+	if err := Watch(context.Background(), changes, 0, func() error { return nil }); err != errWatcherStopped {
+		t.Errorf("closed watcher not reported\nGot:\n%v\nExpected:\n%v", err, errWatcherStopped)
+	}
+}
+
+// waitChange ждёт уведомления от w, которое должно прийти после change.
+// Запоздавшие уведомления о прошлых изменениях сначала вычитываются.
+func waitChange(t *testing.T, w Watcher, what string, change func() error) {
+	t.Helper()
+	for quiet := false; !quiet; {
+		select {
+		case <-w.Changes():
+		case <-time.After(50 * time.Millisecond):
+			quiet = true
+		}
+	}
+	if err := change(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-w.Changes():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no change reported after %s", what)
+	}
+}
+
+func testWatcher(t *testing.T, root string, w Watcher) {
+	waitChange(t, w, "creating a file", func() error {
+		return os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0644)
+	})
+	waitChange(t, w, "creating a directory", func() error {
+		return os.Mkdir(filepath.Join(root, "sub"), 0755)
+	})
+	waitChange(t, w, "writing into the new directory", func() error {
+		return os.WriteFile(filepath.Join(root, "sub", "b.txt"), []byte("b"), 0644)
+	})
+	waitChange(t, w, "removing a file", func() error {
+		return os.Remove(filepath.Join(root, "a.txt"))
+	})
+
+	if err := w.Close(); err != nil {
+		t.Errorf("close failed: %v", err)
+	}
+	if _, ok := <-w.Changes(); ok {
+		t.Errorf("changes not closed after Close")
+	}
+}
+
+func TestPollWatcher(t *testing.T) {
+	root := t.TempDir()
+	testWatcher(t, root, NewPollWatcher(os.DirFS(root), 10*time.Millisecond))
+}
+
+func TestNotifyWatcher(t *testing.T) {
+	root := t.TempDir()
+	w, err := NewNotifyWatcher(root)
+	if err != nil {
+		t.Skip(err)
+	}
+	testWatcher(t, root, w)
+}